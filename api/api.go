@@ -0,0 +1,311 @@
+// Package api implements the REST API of t serve.
+//
+//	GET    /tasks            list tasks, filtered by the query parameters project, context,
+//	                         done (true or false) and due (overdue, today or YYYY-MM-DD for
+//	                         tasks due on or before the date)
+//	POST   /tasks            add a task, the body is {"line": "<todo.txt line>"}
+//	GET    /tasks/{id}       get a task by its short id
+//	PUT    /tasks/{id}       replace a task, the body is {"line": "<todo.txt line>"}
+//	DELETE /tasks/{id}       delete a task
+//	POST   /tasks/{id}/done  complete a task
+//
+// Tasks are returned as JSON objects, see Task. Errors are returned as {"error": "<message>"}.
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	todotxt "github.com/1set/todotxt"
+
+	"t/todo"
+	"t/utils"
+)
+
+// Server serves the REST API for a set of todo files
+type Server struct {
+	// Load reads the todo files for every request
+	Load func() (*todo.Aggregate, error)
+	// Lock guards the todo files against concurrent changes, e.g. by a live sync server
+	Lock sync.Locker
+}
+
+// NewServer creates a server reading the todo files with load
+func NewServer(load func() (*todo.Aggregate, error)) *Server {
+	return &Server{Load: load, Lock: &sync.Mutex{}}
+}
+
+// httpError is an error with the HTTP status code to respond with
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string {
+	return e.err.Error()
+}
+
+func errorf(status int, format string, args ...interface{}) error {
+	return &httpError{status: status, err: fmt.Errorf(format, args...)}
+}
+
+// ServeHTTP routes the requests below /tasks
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "tasks" || len(parts) > 3 || (len(parts) == 3 && parts[2] != "done") {
+		writeError(w, errorf(http.StatusNotFound, "not found"))
+		return
+	}
+
+	var route func(a *todo.Aggregate, r *http.Request) (int, interface{}, error)
+	write := r.Method != http.MethodGet
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		route = s.list
+	case len(parts) == 1 && r.Method == http.MethodPost:
+		route = s.create
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		route = s.withTask(parts[1], get)
+	case len(parts) == 2 && r.Method == http.MethodPut:
+		route = s.withTask(parts[1], replace)
+	case len(parts) == 2 && r.Method == http.MethodDelete:
+		route = s.withTask(parts[1], remove)
+	case len(parts) == 3 && r.Method == http.MethodPost:
+		route = s.withTask(parts[1], complete)
+	default:
+		writeError(w, errorf(http.StatusMethodNotAllowed, "method %s not allowed", r.Method))
+		return
+	}
+
+	s.Lock.Lock()
+	defer s.Lock.Unlock()
+
+	a, err := s.load()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	status, body, err := route(a, r)
+	if err == nil && write {
+		err = a.Write()
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, status, body)
+}
+
+// load reads the todo files and gives every task an id, so it can be addressed
+func (s *Server) load() (*todo.Aggregate, error) {
+	a, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+	missingIDs := false
+	for i := range a.Tasks {
+		if _, ok := todo.TaskIdentifier(&a.Tasks[i]); !ok {
+			todo.EnsureTaskProperties(&a.Tasks[i], todo.TaskEnsureConfig{PreferShortIDs: true})
+			missingIDs = true
+		}
+	}
+	if missingIDs {
+		if err := a.Write(); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+func (s *Server) list(a *todo.Aggregate, r *http.Request) (int, interface{}, error) {
+	filter, err := parseQuery(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	tasks := []Task{}
+	for i := range a.Tasks {
+		if filter(a.Tasks[i]) {
+			tasks = append(tasks, toJSON(a, &a.Tasks[i]))
+		}
+	}
+	return http.StatusOK, tasks, nil
+}
+
+func (s *Server) create(a *todo.Aggregate, r *http.Request) (int, interface{}, error) {
+	task, err := readTask(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if _, ok := todo.TaskIdentifier(task); ok && findTask(a, taskID(task)) != nil {
+		return 0, nil, errorf(http.StatusConflict, "task %s already exists", taskID(task))
+	}
+	todo.EnsureTaskProperties(task, todo.DefaultEnsureConfig)
+	a.AddTask(task)
+	return http.StatusCreated, toJSON(a, &a.Tasks[len(a.Tasks)-1]), nil
+}
+
+// withTask looks up the task with the id for a handler
+func (s *Server) withTask(id string, handler func(a *todo.Aggregate, task *todotxt.Task, r *http.Request) (int, interface{}, error)) func(*todo.Aggregate, *http.Request) (int, interface{}, error) {
+	return func(a *todo.Aggregate, r *http.Request) (int, interface{}, error) {
+		task := findTask(a, id)
+		if task == nil {
+			return 0, nil, errorf(http.StatusNotFound, "task %s not found", id)
+		}
+		return handler(a, task, r)
+	}
+}
+
+func get(a *todo.Aggregate, task *todotxt.Task, r *http.Request) (int, interface{}, error) {
+	return http.StatusOK, toJSON(a, task), nil
+}
+
+func replace(a *todo.Aggregate, task *todotxt.Task, r *http.Request) (int, interface{}, error) {
+	updated, err := readTask(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if _, ok := todo.TaskIdentifier(updated); ok && taskID(updated) != taskID(task) {
+		return 0, nil, errorf(http.StatusBadRequest, "the id of a task cannot be changed")
+	}
+	// Keep the id in its original form
+	for _, key := range []string{"id", "uuid"} {
+		delete(updated.AdditionalTags, key)
+		if value, ok := task.AdditionalTags[key]; ok {
+			updated.AdditionalTags[key] = value
+		}
+	}
+	updated.ID = task.ID
+	*task = *updated
+	return http.StatusOK, toJSON(a, task), nil
+}
+
+func remove(a *todo.Aggregate, task *todotxt.Task, r *http.Request) (int, interface{}, error) {
+	for i := range a.Tasks {
+		if &a.Tasks[i] == task {
+			a.Tasks = append(a.Tasks[:i], a.Tasks[i+1:]...)
+			break
+		}
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+func complete(a *todo.Aggregate, task *todotxt.Task, r *http.Request) (int, interface{}, error) {
+	if !task.Completed {
+		task.Complete()
+	}
+	return http.StatusOK, toJSON(a, task), nil
+}
+
+// parseQuery builds a filter from the query parameters project, context, done and due
+func parseQuery(r *http.Request) (todotxt.Predicate, error) {
+	query := r.URL.Query()
+	var predicates []todotxt.Predicate
+	for _, project := range query["project"] {
+		predicates = append(predicates, todotxt.FilterByProject(project))
+	}
+	for _, context := range query["context"] {
+		predicates = append(predicates, todotxt.FilterByContext(context))
+	}
+
+	switch done := query.Get("done"); done {
+	case "":
+	case "true":
+		predicates = append(predicates, todotxt.FilterCompleted)
+	case "false":
+		predicates = append(predicates, todotxt.FilterNotCompleted)
+	default:
+		return nil, errorf(http.StatusBadRequest, "invalid done %q, expected true or false", done)
+	}
+
+	switch due := query.Get("due"); due {
+	case "":
+	case "overdue":
+		predicates = append(predicates, todotxt.FilterOverdue)
+	case "today":
+		predicates = append(predicates, todotxt.FilterDueToday)
+	default:
+		date, err := time.ParseInLocation(todotxt.DateLayout, due, time.Local)
+		if err != nil {
+			return nil, errorf(http.StatusBadRequest, "invalid due %q, expected overdue, today or YYYY-MM-DD", due)
+		}
+		predicates = append(predicates, func(t todotxt.Task) bool {
+			return t.HasDueDate() && !t.DueDate.After(date)
+		})
+	}
+
+	return func(t todotxt.Task) bool {
+		for _, p := range predicates {
+			if !p(t) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// readTask parses the task in the request body
+func readTask(r *http.Request) (*todotxt.Task, error) {
+	var input TaskInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalid request body: %v", err)
+	}
+	if strings.TrimSpace(input.Line) == "" || strings.Contains(input.Line, "\n") {
+		return nil, errorf(http.StatusBadRequest, "line must be a single todo.txt line")
+	}
+	task, err := todotxt.ParseTask(input.Line)
+	if err != nil {
+		return nil, errorf(http.StatusBadRequest, "invalid task: %v", err)
+	}
+	if task.AdditionalTags == nil {
+		task.AdditionalTags = make(map[string]string)
+	}
+	return task, nil
+}
+
+// findTask returns the task with the id, given in short or long form, or nil
+func findTask(a *todo.Aggregate, id string) *todotxt.Task {
+	uuid, err := utils.DecodeUUID(id)
+	if err != nil {
+		return nil
+	}
+	for i := range a.Tasks {
+		if taskUUID, ok := todo.TaskIdentifier(&a.Tasks[i]); ok && taskUUID == uuid {
+			return &a.Tasks[i]
+		}
+	}
+	return nil
+}
+
+// taskID returns the id of a task in short form
+func taskID(task *todotxt.Task) string {
+	id, _ := todo.TaskIdentifier(task)
+	return utils.ShortEncodeUUID(id)
+}
+
+func toJSON(a *todo.Aggregate, task *todotxt.Task) Task {
+	return newTask(taskID(task), task, a.Source(task))
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	if body == nil {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var httpErr *httpError
+	if errors.As(err, &httpErr) {
+		status = httpErr.status
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"t/todo"
+)
+
+func newTestServer(t *testing.T, content string) (*httptest.Server, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "todo.txt")
+	if err := os.WriteFile(path, []byte(content), 0640); err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(func() (*todo.Aggregate, error) { return todo.ReadTodoFiles([]string{path}, nil) })
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return server, path
+}
+
+func request(t *testing.T, method, url, body string, wantStatus int, result interface{}) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != wantStatus {
+		t.Fatalf("%s %s: status %d, want %d", method, url, resp.StatusCode, wantStatus)
+	}
+	if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			t.Fatalf("%s %s: invalid JSON: %v", method, url, err)
+		}
+	}
+}
+
+func TestTasks(t *testing.T) {
+	server, path := newTestServer(t, "(A) Write chapter +book @desk due:2024-11-01 id:tI4JeTHbMqhXUS9Ig0Pg9t\nBuy milk @shop\n")
+	url := server.URL + "/tasks"
+
+	var tasks []Task
+	request(t, "GET", url, "", http.StatusOK, &tasks)
+	if len(tasks) != 2 {
+		t.Fatalf("GET /tasks returned %d tasks, want 2", len(tasks))
+	}
+	chapter := tasks[0]
+	if chapter.ID != "tI4JeTHbMqhXUS9Ig0Pg9t" || chapter.Priority != "A" || chapter.Due != "2024-11-01" ||
+		chapter.Projects[0] != "book" || !chapter.Overdue || chapter.File != path {
+		t.Errorf("GET /tasks returned %+v", chapter)
+	}
+	if tasks[1].ID == "" {
+		t.Error("task without id did not get one")
+	}
+
+	filters := map[string]int{
+		"?project=book":           1,
+		"?context=shop":           1,
+		"?due=overdue":            1,
+		"?due=2024-10-31":         0,
+		"?done=false":             2,
+		"?project=garden":         0,
+		"?context=desk&done=true": 0,
+	}
+	for query, want := range filters {
+		request(t, "GET", url+query, "", http.StatusOK, &tasks)
+		if len(tasks) != want {
+			t.Errorf("GET /tasks%s returned %d tasks, want %d", query, len(tasks), want)
+		}
+	}
+	request(t, "GET", url+"?due=soon", "", http.StatusBadRequest, nil)
+
+	var created Task
+	request(t, "POST", url, `{"line": "Call mom @phone"}`, http.StatusCreated, &created)
+	if created.ID == "" || created.Created == "" || created.Contexts[0] != "phone" {
+		t.Errorf("POST /tasks returned %+v", created)
+	}
+
+	var task Task
+	request(t, "GET", url+"/"+created.ID, "", http.StatusOK, &task)
+	if task.Text != "Call mom" {
+		t.Errorf("GET /tasks/%s returned %+v", created.ID, task)
+	}
+	request(t, "PUT", url+"/"+created.ID, `{"line": "Call mom tonight @phone"}`, http.StatusOK, &task)
+	if task.Text != "Call mom tonight" || task.ID != created.ID {
+		t.Errorf("PUT /tasks/%s returned %+v", created.ID, task)
+	}
+	request(t, "POST", url+"/"+created.ID+"/done", "", http.StatusOK, &task)
+	if !task.Done || task.Completed == "" {
+		t.Errorf("POST /tasks/%s/done returned %+v", created.ID, task)
+	}
+
+	request(t, "DELETE", url+"/tI4JeTHbMqhXUS9Ig0Pg9t", "", http.StatusNoContent, nil)
+	request(t, "GET", url+"/tI4JeTHbMqhXUS9Ig0Pg9t", "", http.StatusNotFound, nil)
+	request(t, "POST", url, `{"line": ""}`, http.StatusBadRequest, nil)
+	request(t, "PATCH", url, "", http.StatusMethodNotAllowed, nil)
+
+	content, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "Buy milk") || !strings.HasPrefix(lines[1], "x ") {
+		t.Errorf("todo.txt = %q, want milk and the completed call", content)
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"time"
+
+	todo "github.com/1set/todotxt"
+)
+
+// Task is the JSON representation of a task
+type Task struct {
+	ID        string            `json:"id"`   // Short form of the task's id or uuid tag
+	Line      string            `json:"line"` // The task as todo.txt line
+	Text      string            `json:"text"` // Description without dates, priority and tags
+	Priority  string            `json:"priority,omitempty"`
+	Projects  []string          `json:"projects"`
+	Contexts  []string          `json:"contexts"`
+	Tags      map[string]string `json:"tags"`
+	Created   string            `json:"created,omitempty"`   // YYYY-MM-DD
+	Due       string            `json:"due,omitempty"`       // YYYY-MM-DD
+	Completed string            `json:"completed,omitempty"` // YYYY-MM-DD, if completed with a date
+	Done      bool              `json:"done"`
+	Overdue   bool              `json:"overdue"`
+	File      string            `json:"file"` // File the task is stored in
+}
+
+// TaskInput is the request body for creating or replacing a task
+type TaskInput struct {
+	Line string `json:"line"` // The task as todo.txt line
+}
+
+// newTask converts a task to its JSON representation
+func newTask(id string, task *todo.Task, file string) Task {
+	t := Task{
+		ID:       id,
+		Line:     task.String(),
+		Text:     task.Todo,
+		Priority: task.Priority,
+		Projects: nonNil(task.Projects),
+		Contexts: nonNil(task.Contexts),
+		Tags:     task.AdditionalTags,
+		Created:  formatDate(task.CreatedDate),
+		Due:      formatDate(task.DueDate),
+		Done:     task.Completed,
+		Overdue:  !task.Completed && task.IsOverdue(),
+		File:     file,
+	}
+	if task.Completed {
+		t.Completed = formatDate(task.CompletedDate)
+	}
+	if t.Tags == nil {
+		t.Tags = map[string]string{}
+	}
+	return t
+}
+
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(todo.DateLayout)
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"t/sync/git"
+)
+
+// gitCmd represents the git command
+var gitCmd = &cobra.Command{
+	Use:   "git",
+	Short: "Integrate t with git",
+	Long: `t git
+
+	With this command you can set up git repositories containing todo.txt files.
+	`,
+}
+
+// gitInstallDriverCmd represents the git install-driver command
+var gitInstallDriverCmd = &cobra.Command{
+	Use:   "install-driver",
+	Short: "Install the todo.txt merge driver in the current git repository",
+	Long: `t git install-driver
+
+	With this command you can make git merge todo.txt files task by task using
+	t merge-driver. It configures the merge.todotxt driver in the repository config
+	and assigns it to the todo files in .gitattributes.
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		patterns, _ := cmd.Flags().GetStringArray("pattern")
+		command, _ := cmd.Flags().GetString("command")
+		if len(patterns) == 0 {
+			patterns = []string{filepath.Base(todoFile)}
+		}
+
+		repo, err := git.Open(".")
+		if err != nil {
+			log.Fatalf("Failed to open git repository: %v", err)
+		}
+
+		if _, err := repo.Run("config", "merge.todotxt.name", "todo.txt merge driver of t"); err != nil {
+			log.Fatalf("Failed to configure merge driver: %v", err)
+		}
+		if _, err := repo.Run("config", "merge.todotxt.driver", command+" merge-driver %O %A %B"); err != nil {
+			log.Fatalf("Failed to configure merge driver: %v", err)
+		}
+
+		attributesFile := filepath.Join(repo.Dir, ".gitattributes")
+		added, err := addGitAttributes(attributesFile, patterns)
+		if err != nil {
+			log.Fatalf("Failed to update %s: %v", attributesFile, err)
+		}
+		for _, line := range added {
+			fmt.Printf("Added \"%s\" to %s\n", line, attributesFile)
+		}
+		fmt.Println("Merge driver installed")
+	},
+}
+
+// addGitAttributes assigns the todotxt merge driver to the patterns in a .gitattributes file
+// and returns the lines that were added
+func addGitAttributes(path string, patterns []string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	existing := make(map[string]bool)
+	for _, line := range strings.Split(string(content), "\n") {
+		existing[strings.Join(strings.Fields(line), " ")] = true
+	}
+
+	var added []string
+	for _, pattern := range patterns {
+		line := pattern + " merge=todotxt"
+		if !existing[line] {
+			added = append(added, line)
+		}
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		content = append(content, '\n')
+	}
+	content = append(content, []byte(strings.Join(added, "\n")+"\n")...)
+	return added, os.WriteFile(path, content, 0644)
+}
+
+func init() {
+	rootCmd.AddCommand(gitCmd)
+	gitCmd.AddCommand(gitInstallDriverCmd)
+
+	gitInstallDriverCmd.Flags().StringArray("pattern", nil, "File pattern to merge with the driver (default: name of the todo file)")
+	gitInstallDriverCmd.Flags().String("command", "t", "Command git runs to call t")
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"io"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"t/sync/encrypt"
+	"t/sync/git"
+)
+
+// gitFilterCmd represents the git-filter command
+var gitFilterCmd = &cobra.Command{
+	Use:   "git-filter clean|smudge <file>",
+	Short: "Git filter encrypting todo.txt files in the repository",
+	Long: `t git-filter clean|smudge %f
+
+	This command is called by git to keep todo.txt files encrypted in the repository
+	while they are plain text in the working tree, as set up by t sync git --encrypt.
+	It reads the file from standard input and writes the result to standard output.
+
+	clean encrypts the file with the keys of t sync keygen. If the version in the
+	index holds the same tasks, it is kept, so unchanged files stay unchanged.
+	smudge decrypts the file, files that are not encrypted are passed through.
+	`,
+	Args:      cobra.ExactArgs(2),
+	ValidArgs: []string{"clean", "smudge"},
+	Run: func(cmd *cobra.Command, args []string) {
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatalf("Failed to read %s: %v", args[1], err)
+		}
+		keys, err := loadEncryptionKeys()
+		if err != nil {
+			log.Fatalf("Failed to load encryption keys: %v", err)
+		}
+
+		switch args[0] {
+		case "clean":
+			if !encrypt.IsEncrypted(content) {
+				var indexed []byte
+				if repo, err := git.Open("."); err == nil {
+					indexed, _ = repo.Blob(":" + args[1])
+				}
+				if content, err = encrypt.EncryptUnchanged(content, indexed, keys); err != nil {
+					log.Fatalf("Failed to encrypt %s: %v", args[1], err)
+				}
+			}
+		case "smudge":
+			if encrypt.IsEncrypted(content) {
+				if content, err = encrypt.Decrypt(content, keys); err != nil {
+					log.Fatalf("Failed to decrypt %s: %v", args[1], err)
+				}
+			}
+		default:
+			log.Fatalf("Unknown filter %q, expected clean or smudge", args[0])
+		}
+
+		if _, err := os.Stdout.Write(content); err != nil {
+			log.Fatalf("Failed to write %s: %v", args[1], err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(gitFilterCmd)
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"t/sync/encrypt"
+	"t/sync/git"
+)
+
+func TestSyncGitEncrypted(t *testing.T) {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	configHome := t.TempDir()
+	t.Setenv("T_TEST_MAIN", "1")
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_AUTHOR_NAME", "t")
+	t.Setenv("GIT_AUTHOR_EMAIL", "t@example.org")
+	t.Setenv("GIT_COMMITTER_NAME", "t")
+	t.Setenv("GIT_COMMITTER_EMAIL", "t@example.org")
+	if _, err := encrypt.GenerateKeyFile(filepath.Join(configHome, "t", "sync.key")); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	remote := filepath.Join(dir, "remote.git")
+	mustGit(t, dir, "init", "--quiet", "--bare", remote)
+	clone := func(name string) (*git.Repo, git.SyncConfig) {
+		path := filepath.Join(dir, name)
+		mustGit(t, dir, "clone", "--quiet", remote, path)
+		mustGit(t, path, "checkout", "--quiet", "-B", "main")
+		repo, err := git.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo, git.SyncConfig{
+			Files:       []string{filepath.Join(path, "todo.txt")},
+			Remote:      "origin",
+			Branch:      "main",
+			MergeDriver: fmt.Sprintf("%q merge-driver --decrypt %%O %%A %%B", executable),
+			Filter:      fmt.Sprintf("%q git-filter", executable),
+		}
+	}
+	sync := func(repo *git.Repo, config git.SyncConfig) *git.SyncResult {
+		t.Helper()
+		result, err := git.Sync(repo, config)
+		if err != nil {
+			t.Fatalf("Sync() failed: %v", err)
+		}
+		return result
+	}
+	remoteContent := func() string {
+		out, err := runGit(t, remote, "cat-file", "blob", "main:todo.txt")
+		if err != nil {
+			t.Fatalf("reading remote todo.txt: %v: %s", err, out)
+		}
+		return out
+	}
+
+	a, configA := clone("a")
+	os.WriteFile(filepath.Join(a.Dir, "todo.txt"), []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\nBuy milk id:tI4JeTHbMqlSrWPjtn3Zzf\n"), 0644)
+	sync(a, configA)
+	if content := remoteContent(); strings.Contains(content, "chapter") || !encrypt.IsEncrypted([]byte(content)) {
+		t.Fatalf("remote todo.txt is not encrypted: %q", content)
+	}
+	if result := sync(a, configA); result.Committed || result.Pushed {
+		t.Errorf("Sync() without changes = %+v, want nothing to do", result)
+	}
+
+	b, configB := clone("b")
+	sync(b, configB)
+	if content := readTestFile(t, filepath.Join(b.Dir, "todo.txt")); !strings.Contains(content, "Write chapter") {
+		t.Fatalf("todo.txt of b = %q, want plain text", content)
+	}
+
+	// Both clones change different tasks, the merge driver merges the decrypted files
+	os.WriteFile(filepath.Join(a.Dir, "todo.txt"), []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01\nBuy milk id:tI4JeTHbMqlSrWPjtn3Zzf\n"), 0644)
+	sync(a, configA)
+	os.WriteFile(filepath.Join(b.Dir, "todo.txt"), []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\nx Buy milk id:tI4JeTHbMqlSrWPjtn3Zzf\n"), 0644)
+	if result := sync(b, configB); !result.Merged || !result.Pushed {
+		t.Errorf("Sync() = %+v, want merged and pushed", result)
+	}
+	want := "Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01\nx Buy milk id:tI4JeTHbMqlSrWPjtn3Zzf\n"
+	if content := readTestFile(t, filepath.Join(b.Dir, "todo.txt")); content != want {
+		t.Errorf("merged todo.txt = %q, want %q", content, want)
+	}
+	if content := remoteContent(); strings.Contains(content, "chapter") {
+		t.Errorf("merged remote todo.txt is not encrypted: %q", content)
+	}
+
+	// A plain git clone has the encrypted file, the first sync decrypts it
+	c := filepath.Join(dir, "c")
+	mustGit(t, dir, "clone", "--quiet", "--branch", "main", remote, c)
+	if content := readTestFile(t, filepath.Join(c, "todo.txt")); !encrypt.IsEncrypted([]byte(content)) {
+		t.Fatalf("todo.txt of plain clone = %q, want it encrypted", content)
+	}
+	repoC, err := git.Open(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configC := configB
+	configC.Files = []string{filepath.Join(c, "todo.txt")}
+	sync(repoC, configC)
+	if content := readTestFile(t, filepath.Join(c, "todo.txt")); content != want {
+		t.Errorf("todo.txt of plain clone = %q, want %q", content, want)
+	}
+	if result := sync(repoC, configC); result.Committed {
+		t.Errorf("Sync() after decrypting = %+v, want nothing committed", result)
+	}
+}
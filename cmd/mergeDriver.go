@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"t/sync/encrypt"
+	"t/todo"
+)
+
+// mergeDriverCmd represents the merge-driver command
+var mergeDriverCmd = &cobra.Command{
+	Use:   "merge-driver <base> <ours> <theirs>",
+	Short: "Git merge driver for todo.txt files",
+	Long: `t merge-driver %O %A %B
+
+	This command is called by git to merge todo.txt files task by task instead of
+	line by line. Install it with
+		t git install-driver
+
+	The merged tasks are written to <ours>, along with the comment lines of <ours>.
+	The command exits with status 1 only if the same field of a task was changed
+	differently on both sides.
+
+	With --decrypt, the files are decrypted with the keys of t sync keygen before
+	merging and the result is encrypted again, as t sync git --encrypt stores them.
+	`,
+	Args: cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		decrypt, _ := cmd.Flags().GetBool("decrypt")
+
+		var result *todo.MergeResult
+		var err error
+		if decrypt {
+			var keys *encrypt.Keys
+			if keys, err = loadEncryptionKeys(); err != nil {
+				log.Fatalf("Failed to load encryption keys: %v", err)
+			}
+			result, err = encrypt.MergeFiles(args[0], args[1], args[2], keys)
+		} else {
+			result, err = todo.MergeFiles(args[0], args[1], args[2])
+		}
+		if err != nil {
+			log.Fatalf("Failed to merge: %v", err)
+		}
+
+		if len(result.Conflicts) > 0 {
+			printMergeConflicts(result.Conflicts)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Merged todo file: %d matched, %d added, %d deleted\n", result.Matched, result.Added, result.Deleted)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mergeDriverCmd)
+
+	mergeDriverCmd.Flags().Bool("decrypt", false, "Decrypt the files before merging and encrypt the result")
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain runs t instead of the tests if T_TEST_MAIN is set, so git can call the test binary as merge driver
+func TestMain(m *testing.M) {
+	if os.Getenv("T_TEST_MAIN") != "" {
+		Execute()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func runGit(t *testing.T, dir string, args ...string) (string, error) {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	return string(out), err
+}
+
+func mustGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	if out, err := runGit(t, dir, args...); err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+}
+
+// commitTodo writes the todo file on a branch and commits it
+func commitTodo(t *testing.T, dir, branch, content string) {
+	t.Helper()
+	mustGit(t, dir, "checkout", "--quiet", branch)
+	if err := os.WriteFile(filepath.Join(dir, "todo.txt"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mustGit(t, dir, "commit", "--quiet", "-am", "Change todo.txt on "+branch)
+}
+
+func TestMergeDriver(t *testing.T) {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("T_TEST_MAIN", "1")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_AUTHOR_NAME", "t")
+	t.Setenv("GIT_AUTHOR_EMAIL", "t@example.org")
+	t.Setenv("GIT_COMMITTER_NAME", "t")
+	t.Setenv("GIT_COMMITTER_EMAIL", "t@example.org")
+
+	dir := t.TempDir()
+	mustGit(t, dir, "init", "--quiet", "-b", "main")
+	mustGit(t, dir, "config", "merge.todotxt.driver", executable+" merge-driver %O %A %B")
+	if _, err := addGitAttributes(filepath.Join(dir, ".gitattributes"), []string{"todo.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "todo.txt"), []byte("# my list\n"+
+		"Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\n"+
+		"Buy milk id:tI4JeTHbMqlSrWPjtn3Zzf\n"), 0644)
+	mustGit(t, dir, "add", ".")
+	mustGit(t, dir, "commit", "--quiet", "-m", "Add todo.txt")
+	mustGit(t, dir, "branch", "other")
+
+	// Changes of different fields on both sides merge cleanly, comments are kept
+	commitTodo(t, dir, "other", "# my list\n"+
+		"Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01\n"+
+		"Buy milk id:tI4JeTHbMqlSrWPjtn3Zzf\n")
+	commitTodo(t, dir, "main", "# my list\n"+
+		"Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\n"+
+		"(A) Buy milk id:tI4JeTHbMqlSrWPjtn3Zzf\n")
+	if out, err := runGit(t, dir, "merge", "--no-edit", "other"); err != nil {
+		t.Fatalf("git merge failed: %v: %s", err, out)
+	}
+	want := "# my list\n" +
+		"Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01\n" +
+		"(A) Buy milk id:tI4JeTHbMqlSrWPjtn3Zzf\n"
+	if merged := readTestFile(t, filepath.Join(dir, "todo.txt")); merged != want {
+		t.Errorf("merged todo.txt = %q, want %q", merged, want)
+	}
+
+	// The same field changed on both sides is a conflict, our value is kept
+	commitTodo(t, dir, "other", "# my list\n"+
+		"Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-08\n"+
+		"Buy milk id:tI4JeTHbMqlSrWPjtn3Zzf\n")
+	commitTodo(t, dir, "main", "# my list\n"+
+		"Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-15\n"+
+		"(A) Buy milk id:tI4JeTHbMqlSrWPjtn3Zzf\n")
+	out, err := runGit(t, dir, "merge", "--no-edit", "other")
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+		t.Fatalf("git merge with conflict = %v: %s, want exit status 1", err, out)
+	}
+	merged := readTestFile(t, filepath.Join(dir, "todo.txt"))
+	if strings.Contains(merged, "<<<<<<<") || !strings.Contains(merged, "due:2024-11-15") {
+		t.Errorf("todo.txt after conflict = %q, want our due date without conflict markers", merged)
+	}
+}
+
+func TestAddGitAttributes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitattributes")
+	os.WriteFile(path, []byte("*.png binary"), 0644)
+
+	added, err := addGitAttributes(path, []string{"todo.txt", "done.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 2 {
+		t.Errorf("addGitAttributes() added %q, want both patterns", added)
+	}
+
+	// Installing again changes nothing
+	added, err = addGitAttributes(path, []string{"todo.txt", "done.txt"})
+	if err != nil || len(added) != 0 {
+		t.Errorf("second addGitAttributes() = %q, %v, want nothing added", added, err)
+	}
+	want := "*.png binary\ntodo.txt merge=todotxt\ndone.txt merge=todotxt\n"
+	if content := readTestFile(t, path); content != want {
+		t.Errorf(".gitattributes = %q, want %q", content, want)
+	}
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(bs)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"t/api"
+	"t/sync/live"
+	"t/todo"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve your todo list over HTTP",
+	Long: `t serve
+
+	Serves a REST API for your todo.txt file at /tasks:
+		GET    /tasks            list tasks, filtered by ?project=, ?context=,
+		                         ?done=true|false and ?due=overdue|today|YYYY-MM-DD
+		POST   /tasks            add a task from {"line": "<todo.txt line>"}
+		GET    /tasks/{id}       get a task by its short id
+		PUT    /tasks/{id}       replace a task with {"line": "<todo.txt line>"}
+		DELETE /tasks/{id}       delete a task
+		POST   /tasks/{id}/done  complete a task
+
+	It also serves your todo.txt file as the canonical task list for live sync. Other machines
+	connect with
+		t sync live ws://<host>:8080/live
+	and receive every change of a task within seconds, as do all other connected machines.
+	Changes to the todo.txt file on this machine are picked up as well. Other files under
+	todo.files are neither served nor synced.
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := filepath.Abs(todoFile)
+		if err != nil {
+			log.Fatalf("Invalid todo file path: %v", err)
+		}
+		replica, err := live.LoadReplica("serve "+path, path)
+		if err != nil {
+			log.Fatalf("Error loading sync state: %v", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		server := live.NewServer(replica)
+		go server.Watch(ctx)
+
+		// The REST API serves the live synced todo file only, so every change reaches the clients
+		restAPI := api.NewServer(func() (*todo.Aggregate, error) {
+			return todo.ReadTodoFiles([]string{path}, nil)
+		})
+		restAPI.Lock = server
+
+		mux := http.NewServeMux()
+		mux.Handle("/live", server.Handler())
+		mux.Handle("/tasks", restAPI)
+		mux.Handle("/tasks/", restAPI)
+
+		listen := viper.GetString("serve.listen")
+		httpServer := &http.Server{Addr: listen, Handler: mux}
+		go func() {
+			<-ctx.Done()
+			httpServer.Close()
+		}()
+
+		fmt.Printf("Serving %s on %s\n", todoFile, listen)
+		if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatalf("Error serving: %v", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().String("listen", ":8080", "Address to listen on")
+	viper.BindPFlag("serve.listen", serveCmd.Flags().Lookup("listen"))
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"t/status"
+)
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Print a status line for status bars",
+	Long: `t status
+
+	With this command you can show the state of your todo list in a status bar.
+	It prints the number of open and overdue tasks of all todo files.
+
+	Supported formats:
+		plain          a single line of text
+		i3blocks       full text, short text and color; exits with 33 when urgent
+		waybar         JSON for a custom module with return-type json
+		i3status-rust  JSON for a custom block with json = true
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format := status.Format(viper.GetString("status.format"))
+
+		aggregate, err := readTodoFiles()
+		if err != nil {
+			log.Fatalf("Failed to read todo files: %v", err)
+		}
+
+		s := status.FromTaskList(aggregate.Tasks)
+		out, err := s.Render(format)
+		if err != nil {
+			log.Fatalf("Failed to render status: %v", err)
+		}
+		fmt.Print(out)
+
+		if format == status.FormatI3blocks && s.Urgent() {
+			os.Exit(status.I3blocksUrgentExitCode)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+
+	statusCmd.Flags().String("format", string(status.FormatPlain), "Output format: plain, i3blocks, waybar or i3status-rust")
+
+	viper.BindPFlag("status.format", statusCmd.Flags().Lookup("format"))
+}
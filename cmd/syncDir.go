@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"t/sync/dir"
+	"t/sync/remote"
+	"t/sync/state"
+	"t/todo"
+)
+
+var syncDirCmd = &cobra.Command{
+	Use:   "dir [path]",
+	Short: "Mirror your todo file to another directory",
+	Long: `t sync dir [path]
+
+	Keeps your todo.txt file mirrored to another directory, e.g. a mounted share or a
+	Syncthing folder. If path is a directory, the mirror has the name of your todo file.
+
+	If only one side changed since the last sync, it is copied to the other side.
+	If both changed, they are merged task by task, like with t todo merge, and the
+	command exits with status 1 on conflicts.
+
+	Conflict copies left by Syncthing (todo.sync-conflict-*.txt) or Dropbox
+	(todo (... conflicted copy ...).txt) next to either file are merged and removed.
+	Copies with conflicting changes are kept until you resolve the conflicts and
+	delete them, and the command exits with status 1. Kept copies are only merged
+	again if they change.
+	`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := viper.GetString("sync.dir.path")
+		if len(args) > 0 {
+			path = args[0]
+		}
+		if path == "" {
+			fmt.Println("Error: mirror path is not configured")
+			os.Exit(1)
+		}
+		if info, err := os.Stat(path); (err == nil && info.IsDir()) || strings.HasSuffix(path, string(filepath.Separator)) {
+			path = filepath.Join(path, filepath.Base(todoFile))
+		}
+		path, err := filepath.Abs(path)
+		if err != nil {
+			fmt.Printf("Error: invalid path: %v\n", err)
+			os.Exit(1)
+		}
+
+		encrypted, err := encryptionFor("dir")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// The mirror may be the todo file of another t instance on this machine
+		unlock, err := todo.LockFiles([]string{todoFile, path})
+		if err != nil {
+			fmt.Printf("Error locking todo files: %v\n", err)
+			os.Exit(1)
+		}
+		defer unlock()
+
+		copyConflicts := false
+		for _, file := range []struct {
+			path string
+			wrap func(remote.File) remote.File
+		}{{todoFile, nil}, {path, encrypted}} {
+			copies, err := dir.MergeConflictCopies(file.path, file.wrap)
+			if err != nil {
+				fmt.Printf("Error merging conflict copies: %v\n", err)
+				os.Exit(1)
+			}
+			for _, copy := range copies.Removed {
+				fmt.Printf("Merged conflict copy %s\n", copy)
+			}
+			for _, copy := range copies.Kept {
+				fmt.Printf("Merged conflict copy %s with conflicts, kept it for resolving them\n", copy)
+			}
+			for _, copy := range copies.Skipped {
+				fmt.Printf("Conflict copy %s was merged before, delete it once its conflicts are resolved\n", copy)
+			}
+			if len(copies.Conflicts) > 0 {
+				printMergeConflicts(copies.Conflicts)
+				copyConflicts = true
+			}
+		}
+
+		st, err := state.Load("dir", todoFile, path)
+		if err != nil {
+			fmt.Printf("Error loading sync state: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Syncing %s with %s...\n", todoFile, path)
+		result, err := remote.Sync(encrypted(&dir.File{Path: path}), todoFile, st)
+		if err != nil {
+			fmt.Printf("Error during sync: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(result.Conflicts) > 0 {
+			fmt.Printf("\nSync completed with conflicts:\n")
+		} else {
+			fmt.Printf("\nSync completed successfully:\n")
+		}
+		fmt.Printf("  Copied to mirror: %t\n", result.Uploaded)
+		fmt.Printf("  Copied from mirror: %t\n", result.Downloaded)
+		fmt.Printf("  Merged: %t\n", result.Merged)
+		if len(result.Conflicts) > 0 {
+			printMergeConflicts(result.Conflicts)
+		}
+		if len(result.Conflicts) > 0 || copyConflicts {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	syncCmd.AddCommand(syncDirCmd)
+	addEncryptFlag(syncDirCmd, "dir")
+
+	syncDirCmd.PersistentFlags().String("path", "", "Directory or file to mirror your todo file to")
+	viper.BindPFlag("sync.dir.path", syncDirCmd.PersistentFlags().Lookup("path"))
+}
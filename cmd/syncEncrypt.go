@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"t/sync/encrypt"
+	"t/sync/remote"
+)
+
+var syncKeygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Create a key for encrypted sync",
+	Long: `t sync keygen
+
+	Creates an age key file for encrypting remote files, by default sync.key in the t
+	config directory. Copy the key file to your other machines, or create one on each
+	machine and list the public keys of the others under sync.encryption.recipients.
+
+	Encryption is enabled per backend with --encrypt, e.g. t sync webdav --encrypt,
+	or sync.<backend>.encrypt in the config. The remote location then only stores
+	ciphertext, and merging happens after decrypting locally.
+
+	Instead of a key file, sync.encryption.passphrase-command can name a command
+	printing a passphrase, e.g. "pass show t". Recipients cannot be used with it.
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		path := viper.GetString("sync.encryption.key-file")
+		if path == "" {
+			path = encrypt.DefaultKeyFile()
+		}
+		public, err := encrypt.GenerateKeyFile(path)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Created %s\nPublic key: %s\n", path, public)
+	},
+}
+
+// encryptionFor returns a function wrapping remote files with encryption if it is enabled for the backend
+func encryptionFor(backend string) (func(remote.File) remote.File, error) {
+	if !viper.GetBool("sync." + backend + ".encrypt") {
+		return func(file remote.File) remote.File { return file }, nil
+	}
+	keys, err := loadEncryptionKeys()
+	if err != nil {
+		return nil, err
+	}
+	return func(file remote.File) remote.File { return &encrypt.File{File: file, Keys: keys} }, nil
+}
+
+// loadEncryptionKeys loads the keys configured under sync.encryption
+func loadEncryptionKeys() (*encrypt.Keys, error) {
+	var config encrypt.KeyConfig
+	if err := viper.UnmarshalKey("sync.encryption", &config); err != nil {
+		return nil, fmt.Errorf("invalid sync.encryption config: %v", err)
+	}
+	return encrypt.LoadKeys(config)
+}
+
+// addEncryptFlag adds the --encrypt flag to a sync backend command
+func addEncryptFlag(cmd *cobra.Command, backend string) {
+	cmd.PersistentFlags().Bool("encrypt", false, "Store only encrypted content at the remote location, see t sync keygen")
+	viper.BindPFlag("sync."+backend+".encrypt", cmd.PersistentFlags().Lookup("encrypt"))
+}
+
+func init() {
+	syncCmd.AddCommand(syncKeygenCmd)
+}
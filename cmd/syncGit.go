@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"t/sync/git"
+)
+
+var syncGitCmd = &cobra.Command{
+	Use:   "git",
+	Short: "Sync with a git remote",
+	Long: `t sync git
+
+	Syncs your todo.txt file through a git remote. The todo file has to be inside a clone
+	of the remote; additional files like done.txt can be synced with --file.
+
+	Local changes are committed with a generated message, the remote branch is fetched and
+	merged task by task using t merge-driver, and the result is pushed. The remote can be
+	the name of a configured remote or any URL git understands, including the path of a
+	local bare repository.
+
+	The sync refuses to run if the working tree has changes to files it does not sync.
+
+	With --encrypt, the synced files are only stored encrypted in the repository, with
+	the keys of t sync keygen. They stay plain text in the working tree: git converts
+	them with t git-filter during the sync and merges them with t merge-driver --decrypt.
+	Plain git commands on the repository see the encrypted files.
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		remote := viper.GetString("sync.git.remote")
+		if remote == "" {
+			fmt.Println("Error: git remote is not configured")
+			os.Exit(1)
+		}
+
+		executable, err := os.Executable()
+		if err != nil {
+			fmt.Printf("Error locating t executable: %v\n", err)
+			os.Exit(1)
+		}
+		command := fmt.Sprintf("%q", executable)
+		if configFile != "" {
+			command += fmt.Sprintf(" --config %q", configFile)
+		}
+		mergeDriver := command + " merge-driver %O %A %B"
+		var filter string
+		if viper.GetBool("sync.git.encrypt") {
+			// Fail early instead of in every call of the filter
+			if _, err := loadEncryptionKeys(); err != nil {
+				fmt.Printf("Error loading encryption keys: %v\n", err)
+				os.Exit(1)
+			}
+			mergeDriver = command + " merge-driver --decrypt %O %A %B"
+			filter = command + " git-filter"
+		}
+
+		files := []string{todoFile}
+		for _, file := range viper.GetStringSlice("sync.git.files") {
+			files = append(files, filepath.Join(filepath.Dir(todoFile), file))
+		}
+
+		repo, err := git.Open(todoFile)
+		if err != nil {
+			fmt.Printf("Error opening git repository: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Syncing %s with %s...\n", repo.Dir, remote)
+		result, err := git.Sync(repo, git.SyncConfig{
+			Files:       files,
+			Remote:      remote,
+			Branch:      viper.GetString("sync.git.branch"),
+			MergeDriver: mergeDriver,
+			Filter:      filter,
+		})
+		if err != nil {
+			fmt.Printf("Error during sync: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("\nSync completed successfully:\n")
+		fmt.Printf("  Committed local changes: %t\n", result.Committed)
+		fmt.Printf("  Merged remote changes: %t\n", result.Merged)
+		fmt.Printf("  Pushed: %t\n", result.Pushed)
+	},
+}
+
+func init() {
+	syncCmd.AddCommand(syncGitCmd)
+	addEncryptFlag(syncGitCmd, "git")
+
+	syncGitCmd.PersistentFlags().String("remote", "origin", "Git remote to sync with")
+	syncGitCmd.PersistentFlags().String("branch", "", "Remote branch to sync with (default: checked out branch)")
+	syncGitCmd.PersistentFlags().StringSlice("file", nil, "Additional file to sync, relative to the todo file, e.g. done.txt")
+
+	viper.BindPFlag("sync.git.remote", syncGitCmd.PersistentFlags().Lookup("remote"))
+	viper.BindPFlag("sync.git.branch", syncGitCmd.PersistentFlags().Lookup("branch"))
+	viper.BindPFlag("sync.git.files", syncGitCmd.PersistentFlags().Lookup("file"))
+}
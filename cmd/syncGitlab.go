@@ -75,6 +75,13 @@ var syncGitlabCmd = &cobra.Command{
         fmt.Println("Converting GitLab merge requests to tasks...")
         mergeRequestsSourceList := gitlab.CreateMergeRequestTaskList(mergeRequests, mergeRequestPrefix)
         
+        // Lock the todo file against changes by other processes until it is saved
+        fileLock, err := todo.LockFile(todoFile)
+        if err != nil {
+            fmt.Printf("Error locking todo file: %v\n", err)
+            os.Exit(1)
+        }
+
         // Load existing todo file
         fmt.Printf("Loading %s...\n", todoFile)
         targetList, err := todotxt.LoadFromPath(todoFile)
@@ -113,6 +120,14 @@ var syncGitlabCmd = &cobra.Command{
             os.Exit(1)
         }
 
+        fileLock.Unlock()
+
+        // Apply configured splits, which lock the files themselves
+        if err := runConfiguredSplits(); err != nil {
+            fmt.Printf("Error splitting todo file: %v\n", err)
+            os.Exit(1)
+        }
+
         // Print results
         fmt.Printf("\nSync completed successfully:\n")
         fmt.Printf("  Added: %d tasks\n", result.Added)
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"t/sync/live"
+)
+
+var syncLiveCmd = &cobra.Command{
+	Use:   "live [url]",
+	Short: "Sync live with a t serve instance",
+	Long: `t sync live [url]
+
+	Keeps your todo.txt file in sync with a machine running t serve, e.g.
+		t sync live ws://example.org:8080/live
+
+	Every change of a task is sent to the server as it happens, and changes from other
+	machines are applied to your todo.txt file right away. Tasks without id get one.
+	If the connection is lost, t reconnects and exchanges the changes made in the meantime.
+	Runs until interrupted.
+	`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		url := viper.GetString("sync.live.url")
+		if len(args) > 0 {
+			url = args[0]
+		}
+		if url == "" {
+			fmt.Println("Error: live sync URL is not configured")
+			os.Exit(1)
+		}
+
+		path, err := filepath.Abs(todoFile)
+		if err != nil {
+			log.Fatalf("Invalid todo file path: %v", err)
+		}
+		replica, err := live.LoadReplica(url+" "+path, path)
+		if err != nil {
+			log.Fatalf("Error loading sync state: %v", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		fmt.Printf("Syncing %s live with %s...\n", todoFile, url)
+		live.NewClient(url, replica).Run(ctx)
+	},
+}
+
+func init() {
+	syncCmd.AddCommand(syncLiveCmd)
+
+	syncLiveCmd.PersistentFlags().String("url", "", "WebSocket URL of the t serve instance")
+	viper.BindPFlag("sync.live.url", syncLiveCmd.PersistentFlags().Lookup("url"))
+}
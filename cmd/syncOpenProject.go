@@ -53,6 +53,13 @@ var syncOpenProjectCmd = &cobra.Command{
         fmt.Println("Converting work packages to tasks...")
         sourceList := openproject.CreateTaskList(workPackages, prefix, url)
 
+        // Lock the todo file against changes by other processes until it is saved
+        fileLock, err := todo.LockFile(todoFile)
+        if err != nil {
+            fmt.Printf("Error locking todo file: %v\n", err)
+            os.Exit(1)
+        }
+
         // Load existing todo file
         fmt.Printf("Loading %s...\n", todoFile)
         targetList, err := todotxt.LoadFromPath(todoFile)
@@ -76,6 +83,14 @@ var syncOpenProjectCmd = &cobra.Command{
             os.Exit(1)
         }
 
+        fileLock.Unlock()
+
+        // Apply configured splits, which lock the files themselves
+        if err := runConfiguredSplits(); err != nil {
+            fmt.Printf("Error splitting todo file: %v\n", err)
+            os.Exit(1)
+        }
+
         // Print results
         fmt.Printf("\nSync completed successfully:\n")
         fmt.Printf("  Added: %d tasks\n", result.Added)
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"t/sync/remote"
+	"t/sync/sftp"
+	"t/sync/state"
+	"t/todo"
+)
+
+var syncSftpCmd = &cobra.Command{
+	Use:   "sftp [user@]host:path",
+	Short: "Sync with a file on an SSH server",
+	Long: `t sync sftp [user@]host:path
+
+	Syncs your todo.txt file with a file on an SSH server via SFTP, e.g.
+		t sync sftp me@example.org:todo/todo.txt
+	Relative paths are relative to the home directory on the server.
+
+	Authenticates with the keys of the SSH agent and the configured identity files.
+	The host key must already be listed in the known hosts file.
+
+	A remote change is detected by the modification time and a hash of the file.
+	If both the local and the remote file changed since the last sync, they are merged
+	task by task, like with t todo merge, and the command exits with status 1 on
+	conflicts. The remote file is written to a temporary file first and then renamed
+	over the old one.
+	`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		targetString := viper.GetString("sync.sftp.target")
+		if len(args) > 0 {
+			targetString = args[0]
+		}
+		if targetString == "" {
+			fmt.Println("Error: SFTP target is not configured")
+			os.Exit(1)
+		}
+		target, err := sftp.ParseTarget(targetString)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		encrypted, err := encryptionFor("sftp")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fileLock, err := todo.LockFile(todoFile)
+		if err != nil {
+			fmt.Printf("Error locking todo file: %v\n", err)
+			os.Exit(1)
+		}
+		defer fileLock.Unlock()
+
+		st, err := state.Load("sftp", todoFile, target.String())
+		if err != nil {
+			fmt.Printf("Error loading sync state: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Syncing %s with %s...\n", todoFile, target)
+		conn, err := sftp.Dial(target, sftp.DialConfig{
+			Port:           viper.GetInt("sync.sftp.port"),
+			IdentityFiles:  expandHomeAll(viper.GetStringSlice("sync.sftp.identity-files")),
+			KnownHostsFile: expandHome(viper.GetString("sync.sftp.known-hosts")),
+		})
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer conn.Close()
+
+		result, err := remote.Sync(encrypted(&sftp.File{Client: conn.Client, Path: target.Path}), todoFile, st)
+		if err != nil {
+			fmt.Printf("Error during sync: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(result.Conflicts) > 0 {
+			fmt.Printf("\nSync completed with conflicts:\n")
+		} else {
+			fmt.Printf("\nSync completed successfully:\n")
+		}
+		fmt.Printf("  Uploaded: %t\n", result.Uploaded)
+		fmt.Printf("  Downloaded: %t\n", result.Downloaded)
+		fmt.Printf("  Merged: %t\n", result.Merged)
+		if len(result.Conflicts) > 0 {
+			printMergeConflicts(result.Conflicts)
+			os.Exit(1)
+		}
+	},
+}
+
+// expandHome replaces a leading ~/ with the home directory
+func expandHome(path string) string {
+	if len(path) < 2 || path[:2] != "~/" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
+
+func expandHomeAll(paths []string) []string {
+	expanded := make([]string, len(paths))
+	for i, path := range paths {
+		expanded[i] = expandHome(path)
+	}
+	return expanded
+}
+
+func init() {
+	syncCmd.AddCommand(syncSftpCmd)
+	addEncryptFlag(syncSftpCmd, "sftp")
+
+	syncSftpCmd.PersistentFlags().String("target", "", "Remote file as [user@]host:path")
+	syncSftpCmd.PersistentFlags().Int("port", 22, "SSH port")
+	syncSftpCmd.PersistentFlags().StringSlice("identity-file", []string{"~/.ssh/id_ed25519", "~/.ssh/id_ecdsa", "~/.ssh/id_rsa"}, "Private key files without passphrase, used in addition to the SSH agent")
+	syncSftpCmd.PersistentFlags().String("known-hosts", "~/.ssh/known_hosts", "Known hosts file for verifying the host key")
+
+	viper.BindPFlag("sync.sftp.target", syncSftpCmd.PersistentFlags().Lookup("target"))
+	viper.BindPFlag("sync.sftp.port", syncSftpCmd.PersistentFlags().Lookup("port"))
+	viper.BindPFlag("sync.sftp.identity-files", syncSftpCmd.PersistentFlags().Lookup("identity-file"))
+	viper.BindPFlag("sync.sftp.known-hosts", syncSftpCmd.PersistentFlags().Lookup("known-hosts"))
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"t/sync/remote"
+	"t/sync/state"
+	"t/sync/webdav"
+	"t/todo"
+)
+
+var syncWebdavCmd = &cobra.Command{
+	Use:   "webdav",
+	Short: "Sync with a WebDAV server",
+	Long: `t sync webdav
+
+	Syncs your todo.txt file with a file on a WebDAV server, e.g. on Nextcloud:
+		https://cloud.example.org/remote.php/dav/files/<user>/todo.txt
+
+	Uploads only succeed if the remote file was not changed since it was downloaded.
+	If both the local and the remote file changed since the last sync, they are merged
+	task by task, like with t todo merge. The last synced version is kept in the XDG
+	state directory as the common ancestor for this merge. If fields were changed
+	differently on both sides, the conflicts are reported and the command exits with
+	status 1.
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		url := viper.GetString("sync.webdav.url")
+		if url == "" {
+			fmt.Println("Error: WebDAV URL is not configured")
+			os.Exit(1)
+		}
+
+		encrypted, err := encryptionFor("webdav")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fileLock, err := todo.LockFile(todoFile)
+		if err != nil {
+			fmt.Printf("Error locking todo file: %v\n", err)
+			os.Exit(1)
+		}
+		defer fileLock.Unlock()
+
+		st, err := state.Load("webdav", todoFile, url)
+		if err != nil {
+			fmt.Printf("Error loading sync state: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Syncing %s with %s...\n", todoFile, url)
+		client := webdav.NewClient(url, viper.GetString("sync.webdav.username"), viper.GetString("sync.webdav.password"))
+		result, err := remote.Sync(encrypted(client), todoFile, st)
+		if err != nil {
+			fmt.Printf("Error during sync: %v\n", err)
+			os.Exit(1)
+		}
+
+		if len(result.Conflicts) > 0 {
+			fmt.Printf("\nSync completed with conflicts:\n")
+		} else {
+			fmt.Printf("\nSync completed successfully:\n")
+		}
+		fmt.Printf("  Uploaded: %t\n", result.Uploaded)
+		fmt.Printf("  Downloaded: %t\n", result.Downloaded)
+		fmt.Printf("  Merged: %t\n", result.Merged)
+		if len(result.Conflicts) > 0 {
+			printMergeConflicts(result.Conflicts)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	syncCmd.AddCommand(syncWebdavCmd)
+	addEncryptFlag(syncWebdavCmd, "webdav")
+
+	syncWebdavCmd.PersistentFlags().String("url", "", "URL of the todo file on the WebDAV server")
+	syncWebdavCmd.PersistentFlags().String("username", "", "WebDAV username")
+	syncWebdavCmd.PersistentFlags().String("password", "", "WebDAV password or app password")
+
+	viper.BindPFlag("sync.webdav.url", syncWebdavCmd.PersistentFlags().Lookup("url"))
+	viper.BindPFlag("sync.webdav.username", syncWebdavCmd.PersistentFlags().Lookup("username"))
+	viper.BindPFlag("sync.webdav.password", syncWebdavCmd.PersistentFlags().Lookup("password"))
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// timeCmd represents the time command
+var timeCmd = &cobra.Command{
+	Use:   "time",
+	Short: "Track your time",
+	Long: `t time
+
+	With this command you can track the time you spend on your tasks.
+
+	Time is recorded in the timeclock format. See https://hledger.org/time-planning.html
+	`,
+}
+
+func init() {
+	rootCmd.AddCommand(timeCmd)
+
+	timeCmd.PersistentFlags().String("timeclockFile", "timeclock.txt", "timeclock file")
+	viper.BindPFlag("time.file", timeCmd.PersistentFlags().Lookup("timeclockFile"))
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"t/timeclock"
+)
+
+// timeExportCmd represents the time export command
+var timeExportCmd = &cobra.Command{
+	Use:   "export --format timewarrior|hledger-timedot|ical",
+	Short: "Export the timeclock file for other tools",
+	Long: `t time export --format timewarrior|hledger-timedot|ical
+
+	With this command you can convert the entries of your timeclock file for other tools:
+		timewarrior      JSON for timew import
+		hledger-timedot  hours per day and account as hledger timedot journal
+		ical             iCalendar events
+
+	The account, +projects and @contexts of an entry become tags, accounts or categories as
+	fits each format. Entries that are still checked in are skipped.
+	`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		exporter, ok := timeclock.Exporters[format]
+		if !ok {
+			log.Fatalf("Unknown export format %q, expected one of %s", format, strings.Join(exporterNames(), ", "))
+		}
+
+		entries, err := timeclock.ReadFile(viper.GetString("time.file"))
+		if err != nil {
+			log.Fatalf("Failed to read timeclock file: %v", err)
+		}
+		if err := exporter(os.Stdout, entries); err != nil {
+			log.Fatalf("Failed to export: %v", err)
+		}
+	},
+}
+
+func exporterNames() []string {
+	var names []string
+	for name := range timeclock.Exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	timeCmd.AddCommand(timeExportCmd)
+
+	timeExportCmd.Flags().String("format", "", "Format of the export: timewarrior, hledger-timedot or ical")
+	timeExportCmd.MarkFlagRequired("format")
+}
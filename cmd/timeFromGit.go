@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	todotxt "github.com/1set/todotxt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"t/gitlog"
+	"t/utils"
+)
+
+// timeFromGitCmd represents the time from-git command
+var timeFromGitCmd = &cobra.Command{
+	Use:   "from-git <repo>...",
+	Short: "Propose time entries from git commit history",
+	Long: `t time from-git <repo>...
+
+	With this command you can estimate the time you spent on tasks from the commit
+	history of local git repositories.
+
+	Commits are grouped into sessions: a session ends when the pause to the next commit
+	is longer than the gap, and it starts the lead time before its first commit.
+	Commits belong to a task if they have a "Task: <id>" trailer or were made on a branch
+	whose name contains the task's short ID.
+
+	The sessions are printed as timeclock entries for review. Nothing is written.
+	`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		since, err := parseDateFlag(viper.GetString("time.fromgit.since"))
+		if err != nil {
+			log.Fatalf("Invalid --since date: %v", err)
+		}
+		until, err := parseDateFlag(viper.GetString("time.fromgit.until"))
+		if err != nil {
+			log.Fatalf("Invalid --until date: %v", err)
+		}
+		if !until.IsZero() {
+			until = until.AddDate(0, 0, 1)
+		}
+
+		config := gitlog.DefaultSessionConfig
+		config.Gap = viper.GetDuration("time.fromgit.gap")
+		config.Lead = viper.GetDuration("time.fromgit.lead")
+
+		aggregate, err := readTodoFiles()
+		if err != nil {
+			log.Fatalf("Failed to read todo files: %v", err)
+		}
+		taskList := aggregate.Tasks
+
+		var commits []gitlog.Commit
+		for _, repo := range args {
+			author := viper.GetString("time.fromgit.author")
+			if author == "" {
+				if author, err = gitlog.UserEmail(repo); err != nil {
+					log.Fatalf("Failed to determine author, use --author: %v", err)
+				}
+			}
+			repoCommits, err := gitlog.ReadCommits(repo, author, since, until)
+			if err != nil {
+				log.Fatalf("Failed to read git history: %v", err)
+			}
+			commits = append(commits, repoCommits...)
+		}
+
+		sessions := gitlog.EstimateSessions(commits, config, gitlog.NewTaskMatcher(taskList))
+		tasks := tasksByShortID(taskList)
+
+		var total time.Duration
+		for _, s := range sessions {
+			account, description := s.Repo, s.Commits[0].Subject
+			if task, ok := tasks[s.TaskID]; ok {
+				if len(task.Projects) > 0 {
+					account = task.Projects[0]
+				}
+				description = task.Todo + " id:" + s.TaskID
+			}
+			fmt.Println(s.Timeclock(account, description))
+			total += s.Duration()
+		}
+		fmt.Printf("; %d session(s), %s in total\n", len(sessions), utils.FormatEstimate(total))
+	},
+}
+
+// parseDateFlag parses an optional date in todo.txt date format
+func parseDateFlag(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation(todotxt.DateLayout, value, time.Local)
+}
+
+// tasksByShortID indexes the tasks of a todo list by the short form of their ID
+func tasksByShortID(taskList todotxt.TaskList) map[string]todotxt.Task {
+	tasks := make(map[string]todotxt.Task)
+	for _, task := range taskList {
+		for _, key := range []string{"id", "uuid"} {
+			if id, err := utils.DecodeUUID(task.AdditionalTags[key]); err == nil {
+				tasks[utils.ShortEncodeUUID(id)] = task
+			}
+		}
+	}
+	return tasks
+}
+
+func init() {
+	timeCmd.AddCommand(timeFromGitCmd)
+
+	timeFromGitCmd.Flags().String("author", "", "Only consider commits by this author (default: user.email of each repository)")
+	timeFromGitCmd.Flags().String("since", "", "Only consider commits on or after this date (YYYY-MM-DD)")
+	timeFromGitCmd.Flags().String("until", "", "Only consider commits on or before this date (YYYY-MM-DD)")
+	timeFromGitCmd.Flags().Duration("gap", gitlog.DefaultSessionConfig.Gap, "Longest pause between commits of one session")
+	timeFromGitCmd.Flags().Duration("lead", gitlog.DefaultSessionConfig.Lead, "Time credited before the first commit of a session")
+
+	viper.BindPFlag("time.fromgit.author", timeFromGitCmd.Flags().Lookup("author"))
+	viper.BindPFlag("time.fromgit.since", timeFromGitCmd.Flags().Lookup("since"))
+	viper.BindPFlag("time.fromgit.until", timeFromGitCmd.Flags().Lookup("until"))
+	viper.BindPFlag("time.fromgit.gap", timeFromGitCmd.Flags().Lookup("gap"))
+	viper.BindPFlag("time.fromgit.lead", timeFromGitCmd.Flags().Lookup("lead"))
+}
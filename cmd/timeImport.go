@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"t/timeclock"
+	"t/todo"
+	"t/utils"
+)
+
+// timeImportCmd represents the time import command
+var timeImportCmd = &cobra.Command{
+	Use:   "import --from watson|timewarrior|toggl-csv <file>",
+	Short: "Import time entries from other time trackers",
+	Long: `t time import --from watson|timewarrior|toggl-csv <file>
+
+	With this command you can convert the exports of other time trackers into timeclock
+	entries:
+		watson       output of watson log --json
+		timewarrior  output of timew export
+		toggl-csv    detailed report of Toggl Track as CSV
+
+	Projects become the account and a +project, tags become @contexts. If the description
+	matches the text of one of your tasks, the entry gets the task's text and id: tag.
+
+	Entries overlapping an entry of your timeclock file are reported and skipped. The other
+	entries are printed for review, or appended to the timeclock file with --write.
+	`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		from, _ := cmd.Flags().GetString("from")
+		write, _ := cmd.Flags().GetBool("write")
+		importer, ok := timeclock.Importers[from]
+		if !ok {
+			log.Fatalf("Unknown export format %q, expected one of %s", from, strings.Join(importerNames(), ", "))
+		}
+
+		file, err := os.Open(args[0])
+		if err != nil {
+			log.Fatalf("Failed to open export: %v", err)
+		}
+		entries, err := importer(file)
+		file.Close()
+		if err != nil {
+			log.Fatalf("Failed to import %s: %v", args[0], err)
+		}
+
+		aggregate, err := readTodoFiles()
+		if err != nil {
+			log.Fatalf("Failed to read todo files: %v", err)
+		}
+		for i := range entries {
+			matchEntryTask(&entries[i], aggregate)
+		}
+
+		path := viper.GetString("time.file")
+		existing, err := timeclock.ReadFile(path)
+		if err != nil {
+			log.Fatalf("Failed to read timeclock file: %v", err)
+		}
+		overlapping := make(map[int]bool)
+		for _, overlap := range timeclock.Overlaps(existing, entries) {
+			for i := range entries {
+				if entries[i] == overlap.Entry {
+					overlapping[i] = true
+				}
+			}
+			fmt.Fprintf(os.Stderr, "Skipping %s, it overlaps %s\n", formatSpan(overlap.Entry), formatSpan(overlap.Existing))
+		}
+		var imported []timeclock.Entry
+		for i, entry := range entries {
+			if !overlapping[i] {
+				imported = append(imported, entry)
+			}
+		}
+
+		if !write {
+			fmt.Print(timeclock.Format(imported))
+			return
+		}
+		if err := timeclock.AppendFile(path, imported); err != nil {
+			log.Fatalf("Failed to write timeclock file: %v", err)
+		}
+		fmt.Printf("Imported %d entries into %s, skipped %d overlapping\n", len(imported), path, len(overlapping))
+	},
+}
+
+// matchEntryTask replaces the text of an entry's description with the text and id of the task
+// it matches, keeping its projects and contexts
+func matchEntryTask(entry *timeclock.Entry, aggregate *todo.Aggregate) {
+	i := todo.MatchTaskText(aggregate.Tasks, entry.Description)
+	if i < 0 {
+		return
+	}
+	task := &aggregate.Tasks[i]
+	id, ok := todo.TaskIdentifier(task)
+	if !ok {
+		return
+	}
+	if entry.Account == "" && len(task.Projects) > 0 {
+		entry.Account = task.Projects[0]
+	}
+	words := []string{task.Todo}
+	for _, word := range strings.Fields(entry.Description) {
+		if strings.HasPrefix(word, "+") || strings.HasPrefix(word, "@") {
+			words = append(words, word)
+		}
+	}
+	entry.Description = strings.Join(append(words, "id:"+utils.ShortEncodeUUID(id)), " ")
+}
+
+// formatSpan describes an entry by its time span and account
+func formatSpan(e timeclock.Entry) string {
+	end := "now"
+	if !e.End.IsZero() {
+		end = e.End.Local().Format(timeclock.Layout)
+	}
+	return e.Start.Local().Format(timeclock.Layout) + " - " + end + " " + e.Account
+}
+
+func importerNames() []string {
+	var names []string
+	for name := range timeclock.Importers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	timeCmd.AddCommand(timeImportCmd)
+
+	timeImportCmd.Flags().String("from", "", "Format of the export: watson, timewarrior or toggl-csv")
+	timeImportCmd.Flags().Bool("write", false, "Append the entries to the timeclock file instead of printing them")
+	timeImportCmd.MarkFlagRequired("from")
+}
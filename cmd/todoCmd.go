@@ -32,12 +32,18 @@ var todoUpdateCmd = &cobra.Command{
 	- Unique identifiers (short or long form)
 	- Default tags
 
-	It processes the entire todo.txt file and updates task properties according to configuration.
+	It processes all todo files and updates task properties according to configuration.
 	`,
 	Run: func(cmd *cobra.Command, args []string) {
-		taskList, err := todo.ReadTodoFile(todoFile)
+		unlock, err := lockTodoFiles()
 		if err != nil {
-			log.Fatalf("Failed to read todo file: %v", err)
+			log.Fatalf("Failed to lock todo files: %v", err)
+		}
+		defer unlock()
+
+		aggregate, err := readTodoFiles()
+		if err != nil {
+			log.Fatalf("Failed to read todo files: %v", err)
 		}
 
 		config := todo.DefaultEnsureConfig
@@ -47,10 +53,10 @@ var todoUpdateCmd = &cobra.Command{
 			// "version": "1.0",
 		}
 
-		taskList = todo.EnsureTaskListProperties(taskList, config)
+		todo.EnsureTaskListProperties(aggregate.Tasks, config)
 
-		if err = todo.WriteTodoFile(taskList, todoFile); err != nil {
-			log.Fatalf("Failed to write todo file: %v", err)
+		if err = aggregate.Write(); err != nil {
+			log.Fatalf("Failed to write todo files: %v", err)
 		}
 	},
 }
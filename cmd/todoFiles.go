@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	todotxt "github.com/1set/todotxt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"t/todo"
+)
+
+// todoListCmd represents the todo list command
+var todoListCmd = &cobra.Command{
+	Use:   "list [filter...]",
+	Short: "List the tasks of your todo files",
+	Long: `t todo list [filter...]
+
+	Lists the tasks of your todo files, optionally only those matching all filter terms:
+		+project  tasks of the project
+		@context  tasks in the context
+		key:value tasks with the tag
+		word      tasks whose text contains the word
+
+	If several todo files are configured under todo.files, their tasks are listed
+	together with the file each task comes from.
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		aggregate, err := readTodoFiles()
+		if err != nil {
+			log.Fatalf("Failed to read todo files: %v", err)
+		}
+
+		tasks := aggregate.Tasks
+		if len(args) > 0 {
+			filter, err := todo.ParseFilter(args)
+			if err != nil {
+				log.Fatalf("Invalid filter: %v", err)
+			}
+			tasks = tasks.Filter(filter)
+		}
+
+		for i := range tasks {
+			if len(aggregate.Files) > 1 {
+				fmt.Printf("%3d %-12s %s\n", tasks[i].ID, filepath.Base(aggregate.Source(&tasks[i])), tasks[i].String())
+			} else {
+				fmt.Printf("%3d %s\n", tasks[i].ID, tasks[i].String())
+			}
+		}
+	},
+}
+
+// todoAddCmd represents the todo add command
+var todoAddCmd = &cobra.Command{
+	Use:   "add <task>",
+	Short: "Add a task to your todo files",
+	Long: `t todo add <task>
+
+	Adds a task in todo.txt format, e.g.
+		t todo add "(A) Write report +work due:2024-11-01"
+
+	If several todo files are configured under todo.files, the task is added to the file
+	of the first route under todo.routes whose filter matches it, or to the first file.
+	`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		unlock, err := lockTodoFiles()
+		if err != nil {
+			log.Fatalf("Failed to lock todo files: %v", err)
+		}
+		defer unlock()
+
+		aggregate, err := readTodoFiles()
+		if err != nil {
+			log.Fatalf("Failed to read todo files: %v", err)
+		}
+
+		task, err := todotxt.ParseTask(strings.Join(args, " "))
+		if err != nil {
+			log.Fatalf("Invalid task: %v", err)
+		}
+		todo.EnsureTaskProperties(task, todo.DefaultEnsureConfig)
+		file := aggregate.AddTask(task)
+
+		if err := aggregate.Write(); err != nil {
+			log.Fatalf("Failed to write todo files: %v", err)
+		}
+		fmt.Printf("Added task %d to %s\n", task.ID, file)
+	},
+}
+
+// todoFiles returns the todo files configured under todo.files. If --todoFile is given or no
+// files are configured, only the todo file is returned.
+func todoFiles() []string {
+	files := viper.GetStringSlice("todo.files")
+	if len(files) == 0 || rootCmd.PersistentFlags().Changed("todoFile") {
+		files = []string{todoFile}
+	}
+	return files
+}
+
+// lockTodoFiles locks the todo files against changes by other processes, e.g. t serve, until
+// the returned function is called
+func lockTodoFiles() (func(), error) {
+	return todo.LockFiles(todoFiles())
+}
+
+// readTodoFiles reads the todo files as one list, see todoFiles
+func readTodoFiles() (*todo.Aggregate, error) {
+	files := todoFiles()
+
+	var routes []todo.RouteConfig
+	if err := viper.UnmarshalKey("todo.routes", &routes); err != nil {
+		return nil, fmt.Errorf("invalid todo.routes config: %v", err)
+	}
+	if len(files) == 1 {
+		routes = nil
+	}
+	return todo.ReadTodoFiles(files, routes)
+}
+
+func init() {
+	todoCmd.AddCommand(todoListCmd)
+	todoCmd.AddCommand(todoAddCmd)
+}
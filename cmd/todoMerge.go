@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	todotxt "github.com/1set/todotxt"
+	"github.com/spf13/cobra"
+
+	"t/todo"
+)
+
+// todoMergeCmd represents the todo merge command
+var todoMergeCmd = &cobra.Command{
+	Use:   "merge <file> <file>... [--base <file>] [-o <file>]",
+	Short: "Merge multiple todo files by task",
+	Long: `t todo merge <file> <file>... [--base <file>] [-o <file>]
+
+	With this command you can merge todo files that diverged, e.g. on two laptops.
+
+	Tasks are matched by their id: or uuid: tag in either encoding, then by their url:
+	tag and finally by similar text. Matched tasks are merged field by field: completions
+	win, a field changed in only one file takes that change, and a field changed in both
+	files takes the value with the newer modified: tag.
+
+	Without --base, a field set in only one file is taken as added there. With --base,
+	the common ancestor of the files is used to tell changes and deletions apart. Fields that cannot be merged keep the value of the first file and are reported
+	as conflicts; the command then exits with status 1.
+	`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		basePath, _ := cmd.Flags().GetString("base")
+		output, _ := cmd.Flags().GetString("output")
+
+		var base todotxt.TaskList
+		if basePath != "" {
+			var err error
+			if base, err = todo.ReadTodoFile(basePath); err != nil {
+				log.Fatalf("Failed to read base file: %v", err)
+			}
+		}
+
+		merged, err := todo.ReadTodoFile(args[0])
+		if err != nil {
+			log.Fatalf("Failed to read todo file: %v", err)
+		}
+
+		var conflicts []todo.MergeConflict
+		for _, path := range args[1:] {
+			theirs, err := todo.ReadTodoFile(path)
+			if err != nil {
+				log.Fatalf("Failed to read todo file: %v", err)
+			}
+			var result *todo.MergeResult
+			merged, result = todo.MergeTaskLists(base, merged, theirs)
+			fmt.Fprintf(os.Stderr, "Merged %s: %d matched, %d added, %d deleted, %d conflicts\n",
+				path, result.Matched, result.Added, result.Deleted, len(result.Conflicts))
+			conflicts = append(conflicts, result.Conflicts...)
+		}
+
+		if output == "" {
+			fmt.Print(merged.String())
+		} else if err := todo.WriteTodoFile(merged, output); err != nil {
+			log.Fatalf("Failed to write todo file: %v", err)
+		}
+
+		if len(conflicts) > 0 {
+			printMergeConflicts(conflicts)
+			os.Exit(1)
+		}
+	},
+}
+
+// printMergeConflicts reports merge conflicts on stderr
+func printMergeConflicts(conflicts []todo.MergeConflict) {
+	fmt.Fprintf(os.Stderr, "\n%d conflicts, kept the first value:\n", len(conflicts))
+	for _, c := range conflicts {
+		fmt.Fprintf(os.Stderr, "  %s\n    %s: %q <> %q\n", c.Task, c.Field, c.Ours, c.Theirs)
+	}
+}
+
+func init() {
+	todoCmd.AddCommand(todoMergeCmd)
+
+	todoMergeCmd.Flags().String("base", "", "Common ancestor of the files")
+	todoMergeCmd.Flags().StringP("output", "o", "", "Output file (default: standard output)")
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"t/todo"
+)
+
+// todoSplitCmd represents the todo split command
+var todoSplitCmd = &cobra.Command{
+	Use:   "split",
+	Short: "Move matching tasks into a separate todo file",
+	Long: `t todo split --filter <terms> --to <file>
+
+	With this command you can move all tasks matching a filter into a separate file.
+	Filter terms are +project, @context, key:value tags or words of the task text;
+	all terms must match. Moved tasks keep their id: tag.
+
+	With --stub a task with a see:<file> tag is kept in the todo file as a backlink.
+	Relative paths are relative to the directory of the todo file.
+
+	Splits can also be declared in the config and are then applied after every sync:
+
+		todo:
+		  splits:
+		    - filter: ["+thesis"]
+		      to: thesis.txt
+		      stub: true
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		filter, _ := cmd.Flags().GetStringArray("filter")
+		to, _ := cmd.Flags().GetString("to")
+		stub, _ := cmd.Flags().GetBool("stub")
+
+		unlock, err := lockSplitFiles(to)
+		if err != nil {
+			log.Fatalf("Failed to lock todo files: %v", err)
+		}
+		defer unlock()
+
+		result, err := todo.SplitTodoFile(todoFile, todo.SplitConfig{Filter: filter, To: to, Stub: stub})
+		if err != nil {
+			log.Fatalf("Failed to split todo file: %v", err)
+		}
+		fmt.Printf("Moved %d tasks to %s (%d replaced existing copies)\n", result.Moved, to, result.Updated)
+		if len(result.Conflicts) > 0 {
+			printMergeConflicts(result.Conflicts)
+		}
+	},
+}
+
+// todoJoinCmd represents the todo join command
+var todoJoinCmd = &cobra.Command{
+	Use:   "join <file>",
+	Short: "Move the tasks of a split file back into the todo file",
+	Long: `t todo join <file>
+
+	With this command you can reverse a split. All tasks of the split file are moved
+	back into the todo file, stub tasks pointing to it are removed and the split file
+	is deleted.
+	`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		unlock, err := lockSplitFiles(args[0])
+		if err != nil {
+			log.Fatalf("Failed to lock todo files: %v", err)
+		}
+		defer unlock()
+
+		result, err := todo.JoinTodoFile(todoFile, args[0])
+		if err != nil {
+			log.Fatalf("Failed to join todo file: %v", err)
+		}
+		fmt.Printf("Moved %d tasks from %s (%d replaced existing copies)\n", result.Moved, args[0], result.Updated)
+		if len(result.Conflicts) > 0 {
+			printMergeConflicts(result.Conflicts)
+		}
+	},
+}
+
+// runConfiguredSplits applies the splits declared under todo.splits to the todo file.
+// It locks the todo file and the split files, so callers must not hold these locks.
+func runConfiguredSplits() error {
+	var splits []todo.SplitConfig
+	if err := viper.UnmarshalKey("todo.splits", &splits); err != nil {
+		return fmt.Errorf("invalid todo.splits config: %v", err)
+	}
+	if len(splits) == 0 {
+		return nil
+	}
+
+	var targets []string
+	for _, split := range splits {
+		targets = append(targets, split.To)
+	}
+	unlock, err := lockSplitFiles(targets...)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, split := range splits {
+		result, err := todo.SplitTodoFile(todoFile, split)
+		if err != nil {
+			return fmt.Errorf("split to %s failed: %v", split.To, err)
+		}
+		if result.Moved > 0 {
+			fmt.Printf("Moved %d tasks to %s\n", result.Moved, split.To)
+		}
+		if len(result.Conflicts) > 0 {
+			printMergeConflicts(result.Conflicts)
+		}
+	}
+	return nil
+}
+
+// lockSplitFiles locks the todo file and the given split files, relative to the directory of
+// the todo file, against changes by other processes until the returned function is called
+func lockSplitFiles(splitPaths ...string) (func(), error) {
+	paths := []string{todoFile}
+	for _, splitPath := range splitPaths {
+		paths = append(paths, todo.ResolveSplitPath(todoFile, splitPath))
+	}
+	return todo.LockFiles(paths)
+}
+
+func init() {
+	todoCmd.AddCommand(todoSplitCmd)
+	todoCmd.AddCommand(todoJoinCmd)
+
+	todoSplitCmd.Flags().StringArray("filter", nil, "Filter terms selecting the tasks to move, e.g. +thesis")
+	todoSplitCmd.Flags().String("to", "", "File to move the tasks to")
+	todoSplitCmd.Flags().Bool("stub", false, "Keep a stub task with a see: tag in the todo file")
+	todoSplitCmd.MarkFlagRequired("filter")
+	todoSplitCmd.MarkFlagRequired("to")
+}
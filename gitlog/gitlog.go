@@ -0,0 +1,132 @@
+package gitlog
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Commit represents a single commit read from a local git repository
+type Commit struct {
+	Repo    string    // Base name of the repository directory
+	Hash    string    // Full commit hash
+	Author  string    // Author email
+	Time    time.Time // Author date
+	Refs    []string  // Branches that reach the commit, e.g. refs/heads/my-branch
+	Subject string    // First line of the commit message
+	TaskIDs []string  // Values of Task: trailers
+}
+
+// Field and record separators used in the git log format
+const (
+	fieldSep  = "\x1f"
+	recordSep = "\x1e"
+)
+
+var logFormat = strings.Join([]string{
+	"%H", "%ae", "%aI", "%s", "%(trailers:key=Task,valueonly,separator=%x2C)",
+}, "%x1f") + "%x1e"
+
+// ReadCommits reads the commits of all branches of a repository made by the given author
+// between since and until. Zero times leave the respective bound open. Each commit records every
+// branch that reaches it.
+func ReadCommits(repo, author string, since, until time.Time) ([]Commit, error) {
+	out, err := git(repo, "for-each-ref", "--format=%(refname)", "refs/heads")
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	seen := make(map[string]int)
+	for _, ref := range strings.Fields(out) {
+		refCommits, err := readBranch(repo, ref, author, since, until)
+		if err != nil {
+			return nil, err
+		}
+		for _, commit := range refCommits {
+			if i, ok := seen[commit.Hash]; ok {
+				commits[i].Refs = append(commits[i].Refs, ref)
+				continue
+			}
+			seen[commit.Hash] = len(commits)
+			commit.Refs = []string{ref}
+			commits = append(commits, commit)
+		}
+	}
+	return commits, nil
+}
+
+// readBranch reads the commits reachable from a branch
+func readBranch(repo, ref, author string, since, until time.Time) ([]Commit, error) {
+	args := []string{"log", "--format=" + logFormat}
+	if author != "" {
+		args = append(args, "--author="+author)
+	}
+	if !since.IsZero() {
+		args = append(args, "--since="+since.Format(time.RFC3339))
+	}
+	if !until.IsZero() {
+		args = append(args, "--until="+until.Format(time.RFC3339))
+	}
+	args = append(args, ref, "--")
+
+	out, err := git(repo, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	name := filepath.Base(filepath.Clean(repo))
+	var commits []Commit
+	for _, record := range strings.Split(out, recordSep) {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+		fields := strings.Split(record, fieldSep)
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("unexpected git log record in %s: %q", repo, record)
+		}
+		date, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("error parsing commit date %q: %v", fields[2], err)
+		}
+		commit := Commit{
+			Repo:    name,
+			Hash:    fields[0],
+			Author:  fields[1],
+			Time:    date,
+			Subject: fields[3],
+		}
+		for _, id := range strings.Split(fields[4], ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				commit.TaskIDs = append(commit.TaskIDs, id)
+			}
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// UserEmail returns the user.email configured for a repository
+func UserEmail(repo string) (string, error) {
+	out, err := git(repo, "config", "user.email")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// git runs a git command in the given repository and returns its standard output
+func git(repo string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed in %s: %v: %s", args[0], repo, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,43 @@
+package gitlog
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadCommits(t *testing.T) {
+	repo := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+	}
+	git("init", "-q", "-b", "main")
+	git("commit", "-q", "--allow-empty", "-m", "first")
+	git("checkout", "-q", "-b", "fix-tI4JeTHbMqhXUS9Ig0Pg9t")
+	git("commit", "-q", "--allow-empty", "-m", "fix", "-m", "Task: tI4JeTHbMqhXUS9Ig0Pg9t")
+
+	commits, err := ReadCommits(repo, "test@example.com", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("ReadCommits() failed: %v", err)
+	}
+	refs := make(map[string][]string)
+	for _, c := range commits {
+		refs[c.Subject] = c.Refs
+	}
+	want := map[string][]string{
+		"first": {"refs/heads/fix-tI4JeTHbMqhXUS9Ig0Pg9t", "refs/heads/main"},
+		"fix":   {"refs/heads/fix-tI4JeTHbMqhXUS9Ig0Pg9t"},
+	}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("ReadCommits() refs = %v, want %v", refs, want)
+	}
+	for _, c := range commits {
+		if c.Subject == "fix" && !reflect.DeepEqual(c.TaskIDs, []string{"tI4JeTHbMqhXUS9Ig0Pg9t"}) {
+			t.Errorf("TaskIDs = %v, want the Task: trailer", c.TaskIDs)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package gitlog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	todo "github.com/1set/todotxt"
+	"t/utils"
+)
+
+// SessionConfig holds the parameters of the session heuristic
+type SessionConfig struct {
+	// Gap is the longest pause between two commits that still belong to the same session
+	Gap time.Duration
+	// Lead is the time credited before the first commit of a session
+	Lead time.Duration
+}
+
+// DefaultSessionConfig provides sensible defaults for the session heuristic
+var DefaultSessionConfig = SessionConfig{
+	Gap:  2 * time.Hour,
+	Lead: 30 * time.Minute,
+}
+
+// Session is a span of work estimated from consecutive commits
+type Session struct {
+	Start   time.Time
+	End     time.Time
+	Repo    string   // Repository of the first commit
+	TaskID  string   // Short ID of the matched task, empty if no task matched
+	Commits []Commit // Commits in chronological order
+}
+
+// TaskMatcher returns the short ID of the task a commit belongs to, or an empty string
+type TaskMatcher func(Commit) string
+
+// NewTaskMatcher creates a TaskMatcher for the tasks of a todo list. A commit belongs to a task
+// if one of its Task: trailers holds the task's ID in short or long form, or if the name of a
+// branch that reaches it contains the task's short ID.
+func NewTaskMatcher(taskList todo.TaskList) TaskMatcher {
+	shortIDs := make(map[string]bool)
+	for _, task := range taskList {
+		for _, key := range []string{"id", "uuid"} {
+			if id, err := utils.DecodeUUID(task.AdditionalTags[key]); err == nil {
+				shortIDs[utils.ShortEncodeUUID(id)] = true
+			}
+		}
+	}
+
+	return func(c Commit) string {
+		for _, trailer := range c.TaskIDs {
+			if id, err := utils.DecodeUUID(trailer); err == nil {
+				if short := utils.ShortEncodeUUID(id); shortIDs[short] {
+					return short
+				}
+			}
+		}
+		for _, ref := range c.Refs {
+			branch := strings.TrimPrefix(ref, "refs/heads/")
+			for short := range shortIDs {
+				if strings.Contains(branch, short) {
+					return short
+				}
+			}
+		}
+		return ""
+	}
+}
+
+// EstimateSessions groups commits into sessions. A new session starts when the pause since the
+// previous commit exceeds the configured gap or when the commit belongs to another task.
+// Commits without a task are grouped per repository.
+func EstimateSessions(commits []Commit, config SessionConfig, match TaskMatcher) []Session {
+	sorted := make([]Commit, len(commits))
+	copy(sorted, commits)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	var sessions []Session
+	var current *Session
+	for _, c := range sorted {
+		taskID := match(c)
+		if current != nil && c.Time.Sub(current.End) <= config.Gap && sameWork(current, c, taskID) {
+			current.End = c.Time
+			current.Commits = append(current.Commits, c)
+			continue
+		}
+
+		start := c.Time.Add(-config.Lead)
+		if current != nil && start.Before(current.End) {
+			start = current.End
+		}
+		sessions = append(sessions, Session{
+			Start:   start,
+			End:     c.Time,
+			Repo:    c.Repo,
+			TaskID:  taskID,
+			Commits: []Commit{c},
+		})
+		current = &sessions[len(sessions)-1]
+	}
+	return sessions
+}
+
+// sameWork reports whether a commit continues the work of a session
+func sameWork(s *Session, c Commit, taskID string) bool {
+	if s.TaskID != "" || taskID != "" {
+		return s.TaskID == taskID
+	}
+	return s.Repo == c.Repo
+}
+
+// Duration returns the length of the session
+func (s Session) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// timeclockLayout is the date and time layout of timeclock check-in and check-out lines
+const timeclockLayout = "2006/01/02 15:04:05"
+
+// Timeclock renders the session as a commented timeclock check-in and check-out pair
+func (s Session) Timeclock(account, description string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "; %d commit(s) in %s\n", len(s.Commits), s.Repo)
+	for _, c := range s.Commits {
+		fmt.Fprintf(&sb, ";   %.8s %s\n", c.Hash, c.Subject)
+	}
+	fmt.Fprintf(&sb, "i %s %s  %s\n", s.Start.Local().Format(timeclockLayout), account, description)
+	fmt.Fprintf(&sb, "o %s\n", s.End.Local().Format(timeclockLayout))
+	return sb.String()
+}
@@ -0,0 +1,52 @@
+package gitlog
+
+import (
+	"testing"
+	"time"
+
+	todo "github.com/1set/todotxt"
+)
+
+func TestEstimateSessions(t *testing.T) {
+	base := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+
+	taskList := todo.NewTaskList()
+	task, _ := todo.ParseTask("Write thesis id:tI4JeTHbMqhXUS9Ig0Pg9t")
+	taskList.AddTask(task)
+
+	commits := []Commit{
+		{Repo: "a", Time: at(40), Subject: "second"},
+		{Repo: "a", Time: at(0), Subject: "first"},
+		{Repo: "a", Time: at(300), Subject: "after lunch"},
+		{Repo: "a", Time: at(320), Subject: "task", TaskIDs: []string{"tI4JeTHbMqhXUS9Ig0Pg9t"}},
+		{Repo: "a", Time: at(330), Subject: "branch", Refs: []string{"refs/heads/main", "refs/heads/fix-tI4JeTHbMqhXUS9Ig0Pg9t"}},
+	}
+
+	sessions := EstimateSessions(commits, DefaultSessionConfig, NewTaskMatcher(taskList))
+	if len(sessions) != 3 {
+		t.Fatalf("EstimateSessions() returned %d sessions, want 3", len(sessions))
+	}
+
+	tests := []struct {
+		start, end int
+		taskID     string
+		commits    int
+	}{
+		{-30, 40, "", 2},
+		{270, 300, "", 1},
+		{300, 330, "tI4JeTHbMqhXUS9Ig0Pg9t", 2},
+	}
+	for i, tt := range tests {
+		s := sessions[i]
+		if !s.Start.Equal(at(tt.start)) || !s.End.Equal(at(tt.end)) {
+			t.Errorf("session %d = %v - %v, want %v - %v", i, s.Start, s.End, at(tt.start), at(tt.end))
+		}
+		if s.TaskID != tt.taskID {
+			t.Errorf("session %d task = %q, want %q", i, s.TaskID, tt.taskID)
+		}
+		if len(s.Commits) != tt.commits {
+			t.Errorf("session %d has %d commits, want %d", i, len(s.Commits), tt.commits)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	todo "github.com/1set/todotxt"
+)
+
+// Format names a status bar protocol that Render can produce output for
+type Format string
+
+const (
+	FormatPlain        Format = "plain"
+	FormatI3blocks     Format = "i3blocks"
+	FormatWaybar       Format = "waybar"
+	FormatI3statusRust Format = "i3status-rust"
+)
+
+// Formats lists all supported output formats
+var Formats = []Format{FormatPlain, FormatI3blocks, FormatWaybar, FormatI3statusRust}
+
+// Colors used for status bars that support them
+const (
+	colorNormal = "#ffffff"
+	colorUrgent = "#ff5555"
+)
+
+// Status holds the information shown in a status bar
+type Status struct {
+	Open    int // number of open tasks
+	Overdue int // number of open tasks past their due date
+}
+
+// FromTaskList computes the status of a todo list
+func FromTaskList(taskList todo.TaskList) Status {
+	var s Status
+	for i := range taskList {
+		task := &taskList[i]
+		if task.IsCompleted() {
+			continue
+		}
+		s.Open++
+		if task.IsOverdue() {
+			s.Overdue++
+		}
+	}
+	return s
+}
+
+// Urgent reports whether the status should be highlighted
+func (s Status) Urgent() bool {
+	return s.Overdue > 0
+}
+
+// Text returns the long form of the status
+func (s Status) Text() string {
+	if s.Overdue == 0 {
+		return fmt.Sprintf("%d open", s.Open)
+	}
+	return fmt.Sprintf("%d open, %d overdue", s.Open, s.Overdue)
+}
+
+// ShortText returns the abbreviated form of the status
+func (s Status) ShortText() string {
+	if s.Overdue == 0 {
+		return fmt.Sprintf("%d", s.Open)
+	}
+	return fmt.Sprintf("%d!%d", s.Open, s.Overdue)
+}
+
+// Render returns the status in the given format
+func (s Status) Render(format Format) (string, error) {
+	switch format {
+	case FormatPlain:
+		return s.Text() + "\n", nil
+	case FormatI3blocks:
+		return s.renderI3blocks(), nil
+	case FormatWaybar:
+		return s.renderWaybar()
+	case FormatI3statusRust:
+		return s.renderI3statusRust()
+	}
+	return "", fmt.Errorf("unknown status format %q, expected one of %s", format, formatList())
+}
+
+// renderI3blocks renders the full text, short text and color lines of the
+// i3blocks protocol. Urgency is signalled with the exit code, see I3blocksUrgentExitCode.
+func (s Status) renderI3blocks() string {
+	color := colorNormal
+	if s.Urgent() {
+		color = colorUrgent
+	}
+	return strings.Join([]string{s.Text(), s.ShortText(), color}, "\n") + "\n"
+}
+
+// I3blocksUrgentExitCode is the exit code that tells i3blocks to mark a block urgent
+const I3blocksUrgentExitCode = 33
+
+// renderWaybar renders the JSON expected by a waybar custom module with return-type json
+func (s Status) renderWaybar() (string, error) {
+	class := "normal"
+	if s.Urgent() {
+		class = "urgent"
+	}
+	out := struct {
+		Text    string `json:"text"`
+		Alt     string `json:"alt"`
+		Tooltip string `json:"tooltip"`
+		Class   string `json:"class"`
+	}{
+		Text:    s.Text(),
+		Alt:     s.ShortText(),
+		Tooltip: "t: " + s.Text(),
+		Class:   class,
+	}
+	return marshalLine(out)
+}
+
+// renderI3statusRust renders the JSON expected by an i3status-rust custom block with json = true
+func (s Status) renderI3statusRust() (string, error) {
+	state := "Idle"
+	if s.Urgent() {
+		state = "Critical"
+	} else if s.Open > 0 {
+		state = "Info"
+	}
+	out := struct {
+		Icon      string `json:"icon"`
+		State     string `json:"state"`
+		Text      string `json:"text"`
+		ShortText string `json:"short_text"`
+	}{
+		Icon:      "tasks",
+		State:     state,
+		Text:      s.Text(),
+		ShortText: s.ShortText(),
+	}
+	return marshalLine(out)
+}
+
+func marshalLine(v interface{}) (string, error) {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling status: %v", err)
+	}
+	return string(bs) + "\n", nil
+}
+
+func formatList() string {
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return strings.Join(names, ", ")
+}
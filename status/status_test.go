@@ -0,0 +1,58 @@
+package status
+
+import (
+	"strings"
+	"testing"
+
+	todo "github.com/1set/todotxt"
+
+	t_todo "t/todo"
+)
+
+func TestFromTaskList(t *testing.T) {
+	taskList, err := t_todo.ParseTaskList([]byte("Write chapter due:2000-01-01\nBuy milk due:2999-01-01\nCall mom\nx Old task due:2000-01-01\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := FromTaskList(taskList), (Status{Open: 3, Overdue: 1}); got != want {
+		t.Errorf("FromTaskList() = %+v, want %+v", got, want)
+	}
+	if got := FromTaskList(todo.NewTaskList()); got != (Status{}) {
+		t.Errorf("FromTaskList(empty) = %+v, want zero status", got)
+	}
+}
+
+func TestRender(t *testing.T) {
+	calm := Status{Open: 3}
+	urgent := Status{Open: 3, Overdue: 1}
+	tests := []struct {
+		status Status
+		format Format
+		want   string
+	}{
+		{calm, FormatPlain, "3 open\n"},
+		{urgent, FormatPlain, "3 open, 1 overdue\n"},
+		{calm, FormatI3blocks, "3 open\n3\n#ffffff\n"},
+		{urgent, FormatI3blocks, "3 open, 1 overdue\n3!1\n#ff5555\n"},
+		{calm, FormatWaybar, `{"text":"3 open","alt":"3","tooltip":"t: 3 open","class":"normal"}` + "\n"},
+		{urgent, FormatWaybar, `{"text":"3 open, 1 overdue","alt":"3!1","tooltip":"t: 3 open, 1 overdue","class":"urgent"}` + "\n"},
+		{Status{}, FormatI3statusRust, `{"icon":"tasks","state":"Idle","text":"0 open","short_text":"0"}` + "\n"},
+		{calm, FormatI3statusRust, `{"icon":"tasks","state":"Info","text":"3 open","short_text":"3"}` + "\n"},
+		{urgent, FormatI3statusRust, `{"icon":"tasks","state":"Critical","text":"3 open, 1 overdue","short_text":"3!1"}` + "\n"},
+	}
+	for _, tt := range tests {
+		got, err := tt.status.Render(tt.format)
+		if err != nil {
+			t.Errorf("Render(%s) of %+v failed: %v", tt.format, tt.status, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Render(%s) of %+v = %q, want %q", tt.format, tt.status, got, tt.want)
+		}
+	}
+
+	_, err := calm.Render("polybar")
+	if err == nil || !strings.Contains(err.Error(), "i3status-rust") {
+		t.Errorf("Render(polybar) = %v, want error listing the formats", err)
+	}
+}
@@ -0,0 +1,208 @@
+package dir
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/adrg/xdg"
+
+	"t/sync/remote"
+	"t/sync/state"
+	"t/todo"
+)
+
+// ConflictCopies returns the conflict copies file sync tools created of the file at path:
+// Syncthing names them todo.sync-conflict-<date>-<time>-<device>.txt,
+// Dropbox todo (conflicted copy <date>).txt or todo (<name>'s conflicted copy <date>).txt.
+func ConflictCopies(path string) ([]string, error) {
+	ext := filepath.Ext(path)
+	stem := escapeGlob(strings.TrimSuffix(path, ext))
+	ext = escapeGlob(ext)
+
+	var copies []string
+	for _, pattern := range []string{stem + ".sync-conflict-*" + ext, stem + " (*conflicted copy*)" + ext} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		copies = append(copies, matches...)
+	}
+	sort.Strings(copies)
+	return copies, nil
+}
+
+// ConflictCopiesResult contains the outcome of merging conflict copies
+type ConflictCopiesResult struct {
+	Removed   []string // Copies merged without conflicts and removed
+	Kept      []string // Copies with conflicts, kept so their values are not lost
+	Skipped   []string // Copies kept by an earlier merge and not merged again
+	Conflicts []todo.MergeConflict
+}
+
+// MergeConflictCopies merges the conflict copies of the file at path into it task by task.
+// As the common ancestor is unknown, tasks only found in a copy are kept. Copies that merged
+// without conflicts are removed, the others are kept for resolving the conflicts by hand.
+// Kept copies are recorded in the XDG state directory and skipped as long as they are unchanged,
+// so tasks deleted after merging them do not come back.
+// If wrap is not nil, the files are accessed through it, e.g. to decrypt them.
+func MergeConflictCopies(path string, wrap func(remote.File) remote.File) (*ConflictCopiesResult, error) {
+	copies, err := ConflictCopies(path)
+	if err != nil || len(copies) == 0 {
+		return &ConflictCopiesResult{}, err
+	}
+	if wrap == nil {
+		wrap = func(file remote.File) remote.File { return file }
+	}
+	file := wrap(&File{Path: path})
+
+	record, err := loadMergedCopies(path)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[string]string)
+	skipped := make(map[string]bool)
+	for _, copyPath := range copies {
+		bs, err := os.ReadFile(copyPath)
+		if err != nil {
+			return nil, &todo.FileError{Op: "read", Path: copyPath, Err: err}
+		}
+		hashes[copyPath] = state.Hash(bs)
+		if record.has(hashes[copyPath]) {
+			skipped[copyPath] = true
+		}
+	}
+	if len(skipped) == len(copies) {
+		return &ConflictCopiesResult{Skipped: copies}, nil
+	}
+
+	for attempt := 0; ; attempt++ {
+		content, version, _, err := file.Read()
+		if err != nil {
+			return nil, err
+		}
+
+		result := &ConflictCopiesResult{}
+		merged := content
+		for _, copyPath := range copies {
+			if skipped[copyPath] {
+				result.Skipped = append(result.Skipped, copyPath)
+				continue
+			}
+			copyContent, _, _, err := wrap(&File{Path: copyPath}).Read()
+			if err != nil {
+				return nil, err
+			}
+			var mergeResult *todo.MergeResult
+			if merged, mergeResult, err = todo.MergeContents(nil, merged, copyContent); err != nil {
+				return nil, err
+			}
+			if len(mergeResult.Conflicts) > 0 {
+				result.Kept = append(result.Kept, copyPath)
+				result.Conflicts = append(result.Conflicts, mergeResult.Conflicts...)
+			} else {
+				result.Removed = append(result.Removed, copyPath)
+			}
+		}
+
+		_, err = file.Write(merged, version)
+		if err == remote.ErrChanged && attempt < 2 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, copyPath := range result.Removed {
+			if err := os.Remove(copyPath); err != nil {
+				return nil, &todo.FileError{Op: "remove", Path: copyPath, Err: err}
+			}
+		}
+
+		// Only copies that still exist need to be remembered
+		record.Merged = nil
+		for _, copyPath := range append(result.Kept, result.Skipped...) {
+			record.Merged = append(record.Merged, hashes[copyPath])
+		}
+		if err := record.save(); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+}
+
+// mergedCopies records the hashes of the conflict copies of a file that were merged and kept
+type mergedCopies struct {
+	Merged []string `json:"merged"`
+
+	path string // Path of the record file
+}
+
+// loadMergedCopies loads the record of merged conflict copies of the file at path
+func loadMergedCopies(path string) (*mergedCopies, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("error locating sync state: %v", err)
+	}
+	sum := sha256.Sum256([]byte(abs))
+	recordPath, err := xdg.StateFile(filepath.Join("t", "sync", "dir", "copies", hex.EncodeToString(sum[:8])+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("error locating sync state: %v", err)
+	}
+
+	record := &mergedCopies{path: recordPath}
+	bs, err := os.ReadFile(recordPath)
+	if os.IsNotExist(err) {
+		return record, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading sync state: %v", err)
+	}
+	if err := json.Unmarshal(bs, record); err != nil {
+		return nil, fmt.Errorf("error unmarshaling sync state %s: %v", recordPath, err)
+	}
+	return record, nil
+}
+
+func (m *mergedCopies) has(hash string) bool {
+	for _, merged := range m.Merged {
+		if merged == hash {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mergedCopies) save() error {
+	if len(m.Merged) == 0 {
+		if err := os.Remove(m.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error writing sync state: %v", err)
+		}
+		return nil
+	}
+	bs, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling sync state: %v", err)
+	}
+	if err := os.WriteFile(m.path, bs, 0600); err != nil {
+		return fmt.Errorf("error writing sync state: %v", err)
+	}
+	return nil
+}
+
+// escapeGlob escapes the characters of path that have a meaning in glob patterns
+func escapeGlob(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,80 @@
+package dir
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"t/sync/remote"
+)
+
+// File is a todo file in another directory, e.g. on a mounted share or in a Syncthing folder
+type File struct {
+	Path string
+}
+
+// Read reads the file. Its version consists of its modification time and a hash of its content.
+func (f *File) Read() ([]byte, string, bool, error) {
+	info, err := os.Stat(f.Path)
+	if os.IsNotExist(err) {
+		return nil, "", false, nil
+	}
+	if err != nil {
+		return nil, "", false, fmt.Errorf("error getting file info: %v", err)
+	}
+	content, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("error reading file: %v", err)
+	}
+	return content, version(info.ModTime(), content), true, nil
+}
+
+// Write writes the file to a temporary file next to it and renames it over the file, so the file
+// is never seen half written. The version is checked right before the rename.
+func (f *File) Write(content []byte, expected string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0755); err != nil {
+		return "", fmt.Errorf("error creating directory: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), "."+filepath.Base(f.Path)+".t-*")
+	if err != nil {
+		return "", fmt.Errorf("error creating temporary file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("error writing temporary file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("error writing temporary file: %v", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0640); err != nil {
+		return "", fmt.Errorf("error writing temporary file: %v", err)
+	}
+
+	if _, current, _, err := f.Read(); err != nil || current != expected {
+		if err != nil {
+			return "", err
+		}
+		return "", remote.ErrChanged
+	}
+	if err := os.Rename(tmp.Name(), f.Path); err != nil {
+		return "", fmt.Errorf("error renaming temporary file: %v", err)
+	}
+
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return "", fmt.Errorf("error getting file info: %v", err)
+	}
+	return version(info.ModTime(), content), nil
+}
+
+// version identifies a version of the file by its modification time and content
+func version(modTime time.Time, content []byte) string {
+	sum := sha256.Sum256(content)
+	return strconv.FormatInt(modTime.UnixNano(), 10) + "-" + hex.EncodeToString(sum[:8])
+}
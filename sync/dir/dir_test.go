@@ -0,0 +1,149 @@
+package dir
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adrg/xdg"
+
+	"t/sync/remote"
+	"t/sync/state"
+)
+
+func TestSync(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	xdg.Reload()
+
+	dir := t.TempDir()
+	local := filepath.Join(dir, "todo.txt")
+	mirror := &File{Path: filepath.Join(dir, "share", "todo.txt")}
+	syncFile := func() *remote.SyncResult {
+		t.Helper()
+		st, err := state.Load("dir", local, mirror.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := remote.Sync(mirror, local, st)
+		if err != nil {
+			t.Fatalf("Sync() failed: %v", err)
+		}
+		return result
+	}
+
+	os.WriteFile(local, []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\n"), 0640)
+	if result := syncFile(); !result.Uploaded || result.Merged {
+		t.Errorf("first Sync() = %+v, want copy to mirror", result)
+	}
+
+	// One-sided change is copied
+	os.WriteFile(mirror.Path, []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01\n"), 0640)
+	if result := syncFile(); !result.Downloaded || result.Merged {
+		t.Errorf("Sync() after mirror change = %+v, want copy from mirror", result)
+	}
+
+	// Two-sided change is merged
+	os.WriteFile(mirror.Path, []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01\nCall mom\n"), 0640)
+	os.WriteFile(local, []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01\nBuy milk\n"), 0640)
+	if result := syncFile(); !result.Merged {
+		t.Errorf("Sync() after both changed = %+v, want merge", result)
+	}
+	content, _ := os.ReadFile(mirror.Path)
+	if !strings.Contains(string(content), "Call mom") || !strings.Contains(string(content), "Buy milk") {
+		t.Errorf("mirror = %q, want both new tasks", content)
+	}
+
+	// Stale writes fail
+	if _, err := mirror.Write([]byte("stale\n"), "0-stale"); err != remote.ErrChanged {
+		t.Errorf("Write() with stale version = %v, want remote.ErrChanged", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(mirror.Path)); len(entries) != 1 {
+		t.Errorf("mirror directory has %d entries, want only todo.txt", len(entries))
+	}
+}
+
+func TestMergeConflictCopies(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	xdg.Reload()
+
+	dir := t.TempDir()
+	file := &File{Path: filepath.Join(dir, "todo.txt")}
+	os.WriteFile(file.Path, []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\n"), 0640)
+	syncthing := filepath.Join(dir, "todo.sync-conflict-20241019-101500-ABCDEFG.txt")
+	os.WriteFile(syncthing, []byte("x 2024-10-19 Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\n"), 0640)
+	dropbox := filepath.Join(dir, "todo (Laptop's conflicted copy 2024-10-19).txt")
+	os.WriteFile(dropbox, []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\nBuy milk\n"), 0640)
+	unrelated := filepath.Join(dir, "done.sync-conflict-20241019-101500-ABCDEFG.txt")
+	os.WriteFile(unrelated, []byte("Call mom\n"), 0640)
+
+	result, err := MergeConflictCopies(file.Path, nil)
+	if err != nil {
+		t.Fatalf("MergeConflictCopies() failed: %v", err)
+	}
+	if len(result.Removed) != 2 || len(result.Kept) != 0 {
+		t.Errorf("MergeConflictCopies() = %+v, want the two copies of todo.txt removed", result)
+	}
+
+	want := "x 2024-10-19 Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\nBuy milk\n"
+	if content, _ := os.ReadFile(file.Path); string(content) != want {
+		t.Errorf("todo.txt = %q, want %q", content, want)
+	}
+	for _, path := range []string{syncthing, dropbox} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s was not removed", filepath.Base(path))
+		}
+	}
+	if _, err := os.Stat(unrelated); err != nil {
+		t.Errorf("conflict copy of another file was touched: %v", err)
+	}
+}
+
+func TestMergeConflictCopiesWithConflicts(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	xdg.Reload()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "todo.txt")
+	os.WriteFile(path, []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01\n"), 0640)
+	conflicting := filepath.Join(dir, "todo.sync-conflict-20241019-101500-ABCDEFG.txt")
+	os.WriteFile(conflicting, []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-08\nBuy milk\n"), 0640)
+
+	result, err := MergeConflictCopies(path, nil)
+	if err != nil {
+		t.Fatalf("MergeConflictCopies() failed: %v", err)
+	}
+	if len(result.Kept) != 1 || len(result.Removed) != 0 || len(result.Conflicts) != 1 {
+		t.Errorf("MergeConflictCopies() = %+v, want the copy kept with one conflict", result)
+	}
+	if _, err := os.Stat(conflicting); err != nil {
+		t.Errorf("conflict copy with conflicts was removed: %v", err)
+	}
+	want := "Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01\nBuy milk\n"
+	if content, _ := os.ReadFile(path); string(content) != want {
+		t.Errorf("todo.txt = %q, want %q", content, want)
+	}
+
+	// The kept copy is not merged again, so a task deleted afterwards does not come back
+	os.WriteFile(path, []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01\n"), 0640)
+	result, err = MergeConflictCopies(path, nil)
+	if err != nil {
+		t.Fatalf("MergeConflictCopies() failed: %v", err)
+	}
+	if len(result.Skipped) != 1 || len(result.Kept) != 0 || len(result.Conflicts) != 0 {
+		t.Errorf("MergeConflictCopies() = %+v, want the copy skipped", result)
+	}
+	if content, _ := os.ReadFile(path); strings.Contains(string(content), "Buy milk") {
+		t.Errorf("todo.txt = %q, want the deleted task to stay deleted", content)
+	}
+
+	// A changed copy is merged again
+	os.WriteFile(conflicting, []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-08\nCall mom\n"), 0640)
+	result, err = MergeConflictCopies(path, nil)
+	if err != nil {
+		t.Fatalf("MergeConflictCopies() failed: %v", err)
+	}
+	if len(result.Kept) != 1 || len(result.Skipped) != 0 {
+		t.Errorf("MergeConflictCopies() = %+v, want the changed copy merged", result)
+	}
+}
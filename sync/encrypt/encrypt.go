@@ -0,0 +1,94 @@
+package encrypt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+
+	"t/sync/remote"
+)
+
+// File encrypts the content of a remote file, so the remote location only ever stores ciphertext.
+// Contents are decrypted on reading, so syncing and merging work on the plain text.
+type File struct {
+	remote.File
+	Keys *Keys
+}
+
+// Read reads and decrypts the remote file
+func (f *File) Read() ([]byte, string, bool, error) {
+	content, version, exists, err := f.File.Read()
+	if err != nil || !exists {
+		return content, version, exists, err
+	}
+	plain, err := Decrypt(content, f.Keys)
+	if err != nil {
+		return nil, "", false, err
+	}
+	return plain, version, true, nil
+}
+
+// Write encrypts content and writes it to the remote file
+func (f *File) Write(content []byte, version string) (string, error) {
+	encrypted, err := Encrypt(content, f.Keys)
+	if err != nil {
+		return "", err
+	}
+	return f.File.Write(encrypted, version)
+}
+
+// Encrypt encrypts content to the recipients of the keys, ASCII armored
+func Encrypt(content []byte, keys *Keys) ([]byte, error) {
+	var buf bytes.Buffer
+	armored := armor.NewWriter(&buf)
+	w, err := age.Encrypt(armored, keys.Recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting: %v", err)
+	}
+	if _, err := w.Write(content); err != nil {
+		return nil, fmt.Errorf("error encrypting: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("error encrypting: %v", err)
+	}
+	if err := armored.Close(); err != nil {
+		return nil, fmt.Errorf("error encrypting: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// EncryptUnchanged encrypts content like Encrypt, but returns previous if it is the encryption of
+// the same content. Encryption is randomized, so this keeps unchanged files unchanged, e.g. in git.
+func EncryptUnchanged(content, previous []byte, keys *Keys) ([]byte, error) {
+	if IsEncrypted(previous) {
+		if plain, err := Decrypt(previous, keys); err == nil && bytes.Equal(plain, content) {
+			return previous, nil
+		}
+	}
+	return Encrypt(content, keys)
+}
+
+// IsEncrypted reports whether content is age encrypted, ASCII armored or binary
+func IsEncrypted(content []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(content), []byte(armor.Header)) || bytes.HasPrefix(content, []byte("age-encryption.org/"))
+}
+
+// Decrypt decrypts content encrypted by Encrypt, or binary age content
+func Decrypt(content []byte, keys *Keys) ([]byte, error) {
+	var src io.Reader = bytes.NewReader(content)
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte(armor.Header)) {
+		src = armor.NewReader(src)
+	}
+	r, err := age.Decrypt(src, keys.Identities...)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting remote file: %v", err)
+	}
+	plain, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting remote file: %v", err)
+	}
+	return plain, nil
+}
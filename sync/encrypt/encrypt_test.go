@@ -0,0 +1,98 @@
+package encrypt
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adrg/xdg"
+
+	"t/sync/dir"
+	"t/sync/remote"
+	"t/sync/state"
+)
+
+func TestSync(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	xdg.Reload()
+
+	tmp := t.TempDir()
+	keyFile := filepath.Join(tmp, "sync.key")
+	if _, err := GenerateKeyFile(keyFile); err != nil {
+		t.Fatalf("GenerateKeyFile() failed: %v", err)
+	}
+	if _, err := GenerateKeyFile(keyFile); err == nil {
+		t.Error("GenerateKeyFile() overwrote an existing key file")
+	}
+	keys, err := LoadKeys(KeyConfig{KeyFile: keyFile})
+	if err != nil {
+		t.Fatalf("LoadKeys() failed: %v", err)
+	}
+
+	mirror := filepath.Join(tmp, "share", "todo.txt")
+	file := &File{File: &dir.File{Path: mirror}, Keys: keys}
+	syncFile := func(name string) *remote.SyncResult {
+		t.Helper()
+		st, err := state.Load("dir", filepath.Join(tmp, name), mirror)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := remote.Sync(file, filepath.Join(tmp, name), st)
+		if err != nil {
+			t.Fatalf("Sync(%s) failed: %v", name, err)
+		}
+		return result
+	}
+
+	os.WriteFile(filepath.Join(tmp, "a.txt"), []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\n"), 0640)
+	syncFile("a.txt")
+	syncFile("b.txt")
+	os.WriteFile(filepath.Join(tmp, "a.txt"), []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\nBuy milk\n"), 0640)
+	os.WriteFile(filepath.Join(tmp, "b.txt"), []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01\n"), 0640)
+	syncFile("a.txt")
+	if result := syncFile("b.txt"); !result.Merged {
+		t.Errorf("Sync() after both changed = %+v, want merge", result)
+	}
+
+	want := "Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01\nBuy milk\n"
+	if content, _ := os.ReadFile(filepath.Join(tmp, "b.txt")); string(content) != want {
+		t.Errorf("b.txt = %q, want %q", content, want)
+	}
+	ciphertext, _ := os.ReadFile(mirror)
+	if bytes.Contains(ciphertext, []byte("chapter")) || !strings.HasPrefix(string(ciphertext), "-----BEGIN AGE ENCRYPTED FILE-----") {
+		t.Errorf("mirror is not encrypted: %q", ciphertext)
+	}
+
+	// Other keys cannot read the file
+	otherKeyFile := filepath.Join(tmp, "other.key")
+	GenerateKeyFile(otherKeyFile)
+	otherKeys, _ := LoadKeys(KeyConfig{KeyFile: otherKeyFile})
+	if _, err := Decrypt(ciphertext, otherKeys); err == nil {
+		t.Error("Decrypt() with another key succeeded")
+	}
+}
+
+func TestPassphraseCommand(t *testing.T) {
+	keys, err := LoadKeys(KeyConfig{PassphraseCommand: "echo correct horse battery staple"})
+	if err != nil {
+		t.Fatalf("LoadKeys() failed: %v", err)
+	}
+	ciphertext, err := Encrypt([]byte("Buy milk\n"), keys)
+	if err != nil {
+		t.Fatalf("Encrypt() failed: %v", err)
+	}
+	plain, err := Decrypt(ciphertext, keys)
+	if err != nil || string(plain) != "Buy milk\n" {
+		t.Errorf("Decrypt() = %q, %v, want the plain text", plain, err)
+	}
+
+	if _, err := LoadKeys(KeyConfig{PassphraseCommand: "exit 1"}); err == nil {
+		t.Error("LoadKeys() with failing passphrase command succeeded")
+	}
+	recipient := "age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"
+	if _, err := LoadKeys(KeyConfig{PassphraseCommand: "echo secret", Recipients: []string{recipient}}); err == nil {
+		t.Error("LoadKeys() with passphrase command and recipients succeeded")
+	}
+}
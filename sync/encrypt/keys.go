@@ -0,0 +1,128 @@
+package encrypt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/adrg/xdg"
+)
+
+// KeyConfig tells where the keys for encrypting remote files come from
+type KeyConfig struct {
+	// KeyFile is an age identity file, by default t/sync.key in the XDG config directory
+	KeyFile string `mapstructure:"key-file"`
+	// PassphraseCommand is a shell command printing a passphrase, e.g. "pass show t".
+	// If set, files are encrypted with the passphrase instead of the key file.
+	PassphraseCommand string `mapstructure:"passphrase-command"`
+	// Recipients are additional age public keys files are encrypted to, e.g. of other machines
+	// with their own key file. They cannot be combined with PassphraseCommand.
+	Recipients []string `mapstructure:"recipients"`
+}
+
+// Keys holds the identities for decrypting and the recipients for encrypting
+type Keys struct {
+	Identities []age.Identity
+	Recipients []age.Recipient
+}
+
+// DefaultKeyFile returns the path of the default key file in the XDG config directory
+func DefaultKeyFile() string {
+	return filepath.Join(xdg.ConfigHome, "t", "sync.key")
+}
+
+// LoadKeys loads the keys according to config
+func LoadKeys(config KeyConfig) (*Keys, error) {
+	if config.PassphraseCommand != "" {
+		return passphraseKeys(config)
+	}
+
+	path := config.KeyFile
+	if path == "" {
+		path = DefaultKeyFile()
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("key file %s does not exist, create one with t sync keygen or copy it from another machine", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening key file: %v", err)
+	}
+	defer file.Close()
+
+	identities, err := age.ParseIdentities(file)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing key file %s: %v", path, err)
+	}
+	keys := &Keys{Identities: identities}
+	for _, identity := range identities {
+		if x25519, ok := identity.(*age.X25519Identity); ok {
+			keys.Recipients = append(keys.Recipients, x25519.Recipient())
+		}
+	}
+	for _, recipient := range config.Recipients {
+		r, err := age.ParseX25519Recipient(recipient)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %v", recipient, err)
+		}
+		keys.Recipients = append(keys.Recipients, r)
+	}
+	return keys, nil
+}
+
+// passphraseKeys derives the keys from the passphrase printed by the passphrase command.
+// A passphrase cannot be combined with recipients, files encrypted to both would need either.
+func passphraseKeys(config KeyConfig) (*Keys, error) {
+	if len(config.Recipients) > 0 {
+		return nil, fmt.Errorf("recipients cannot be used with a passphrase command, use a key file instead")
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("sh", "-c", config.PassphraseCommand)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("passphrase command failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	passphrase := strings.TrimRight(string(out), "\r\n")
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase command printed no passphrase")
+	}
+
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return &Keys{Identities: []age.Identity{identity}, Recipients: []age.Recipient{recipient}}, nil
+}
+
+// GenerateKeyFile writes a new age identity to path and returns its public key.
+// An existing key file is never overwritten.
+func GenerateKeyFile(path string) (string, error) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("error creating key directory: %v", err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", fmt.Errorf("error creating key file: %v", err)
+	}
+	defer file.Close()
+
+	public := identity.Recipient().String()
+	if _, err := fmt.Fprintf(file, "# public key: %s\n%s\n", public, identity); err != nil {
+		return "", fmt.Errorf("error writing key file: %v", err)
+	}
+	return public, file.Close()
+}
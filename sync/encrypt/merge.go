@@ -0,0 +1,39 @@
+package encrypt
+
+import (
+	"os"
+
+	"t/todo"
+)
+
+// MergeFiles merges encrypted todo files like todo.MergeFiles: base, ours and theirs are
+// decrypted, merged, and the result is written to ours encrypted. Files that are not encrypted,
+// e.g. from before encryption was enabled, are merged as they are.
+func MergeFiles(base, ours, theirs string, keys *Keys) (*todo.MergeResult, error) {
+	var contents [3][]byte
+	for i, path := range []string{base, ours, theirs} {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, &todo.FileError{Op: "read", Path: path, Err: err}
+		}
+		if IsEncrypted(content) {
+			if content, err = Decrypt(content, keys); err != nil {
+				return nil, err
+			}
+		}
+		contents[i] = content
+	}
+
+	merged, result, err := todo.MergeContents(contents[0], contents[1], contents[2])
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := Encrypt(merged, keys)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(ours, encrypted, 0644); err != nil {
+		return nil, &todo.FileError{Op: "write", Path: ours, Err: err}
+	}
+	return result, nil
+}
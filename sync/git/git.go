@@ -0,0 +1,124 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Repo is a local git working tree
+type Repo struct {
+	Dir     string   // Top level directory of the working tree
+	Options []string // Configuration passed to every git command with -c, e.g. filter.todotxt.clean=...
+}
+
+// Open returns the repository containing the given file or directory
+func Open(path string) (*Repo, error) {
+	dir := path
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+	root, err := (&Repo{Dir: dir}).Run("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("%s is not in a git repository: %v", path, err)
+	}
+	return &Repo{Dir: root}, nil
+}
+
+// Run runs a git command in the repository and returns its trimmed standard output
+func (r *Repo) Run(args ...string) (string, error) {
+	out, err := r.output(args...)
+	return strings.TrimSpace(out), err
+}
+
+// output runs a git command in the repository and returns its standard output
+func (r *Repo) output(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	global := []string{"-C", r.Dir}
+	for _, option := range r.Options {
+		global = append(global, "-c", option)
+	}
+	cmd := exec.Command("git", append(global, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %v: %s", subcommand(args), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// Blob returns the content of a blob as stored in the repository, e.g. of :todo.txt in the index
+func (r *Repo) Blob(object string) ([]byte, error) {
+	out, err := r.output("cat-file", "blob", object)
+	return []byte(out), err
+}
+
+// RelPath returns the path of a file relative to the top level directory of the repository
+func (r *Repo) RelPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	// Resolve symlinks on both sides, git reports the real path of the top level directory
+	if resolved, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		abs = filepath.Join(resolved, filepath.Base(abs))
+	}
+	rel, err := filepath.Rel(r.Dir, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s is outside of the repository %s", path, r.Dir)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// Changes returns the paths of all changed and untracked files in the working tree
+func (r *Repo) Changes() ([]string, error) {
+	out, err := r.output("status", "--porcelain", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		paths = append(paths, entry[3:])
+		// Renames and copies are followed by their source path
+		if entry[0] == 'R' || entry[0] == 'C' {
+			i++
+		}
+	}
+	return paths, nil
+}
+
+// CurrentBranch returns the name of the checked out branch
+func (r *Repo) CurrentBranch() (string, error) {
+	return r.Run("symbolic-ref", "--short", "HEAD")
+}
+
+// HasRef reports whether a ref like origin/main exists
+func (r *Repo) HasRef(ref string) bool {
+	_, err := r.Run("rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	return err == nil
+}
+
+// HasCommits reports whether the checked out branch has any commits yet
+func (r *Repo) HasCommits() bool {
+	return r.HasRef("HEAD")
+}
+
+// subcommand returns the git subcommand of an argument list, skipping -c options
+func subcommand(args []string) string {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "-c" {
+			i++
+			continue
+		}
+		return args[i]
+	}
+	return ""
+}
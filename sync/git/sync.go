@@ -0,0 +1,237 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SyncConfig holds the options of a git sync
+type SyncConfig struct {
+	// Files are the files to sync, e.g. todo.txt and done.txt
+	Files []string
+	// Remote is the git remote to sync with, a remote name or any URL git understands
+	Remote string
+	// Branch is the remote branch to sync with, defaults to the checked out branch
+	Branch string
+	// MergeDriver is the command git runs to merge the files, e.g. "t merge-driver %O %A %B".
+	// If empty, git merges the files line by line.
+	MergeDriver string
+	// Filter is the command git runs to convert the files between working tree and repository,
+	// e.g. "t git-filter". It is called with clean or smudge and the path of the file, reading
+	// the content from standard input. If empty, the files are stored as they are.
+	Filter string
+}
+
+// SyncResult contains information about a git sync
+type SyncResult struct {
+	Committed bool // Local changes were committed
+	Merged    bool // Remote changes were merged
+	Pushed    bool // Local commits were pushed
+}
+
+// Sync commits local changes of the files, merges the remote branch and pushes the result.
+// It refuses to run if the working tree has changes to any other file.
+func Sync(repo *Repo, config SyncConfig) (*SyncResult, error) {
+	result := &SyncResult{}
+
+	files := make(map[string]bool)
+	var synced []string
+	for _, file := range config.Files {
+		rel, err := repo.RelPath(file)
+		if err != nil {
+			return nil, err
+		}
+		files[rel] = true
+		synced = append(synced, rel)
+	}
+
+	// The merge driver and filter are set up through a temporary attributes file and passed to
+	// every git command, leaving the repository configuration untouched
+	options, cleanup, err := attributeOptions(synced, config)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	repo = &Repo{Dir: repo.Dir, Options: append(append([]string{}, repo.Options...), options...)}
+
+	branch := config.Branch
+	if branch == "" {
+		var err error
+		if branch, err = repo.CurrentBranch(); err != nil {
+			return nil, fmt.Errorf("cannot determine branch: %v", err)
+		}
+	}
+
+	// Check the remote before committing anything, an unreachable remote fails the sync early
+	remoteHead, err := repo.Run("ls-remote", "--heads", config.Remote, "refs/heads/"+branch)
+	if err != nil {
+		return nil, err
+	}
+
+	// Commit local changes
+	changes, err := repo.Changes()
+	if err != nil {
+		return nil, err
+	}
+	var unrelated, changed []string
+	for _, path := range changes {
+		if files[path] {
+			changed = append(changed, path)
+		} else {
+			unrelated = append(unrelated, path)
+		}
+	}
+	if len(unrelated) > 0 {
+		return nil, fmt.Errorf("working tree has unrelated changes, commit or stash them first: %s", strings.Join(unrelated, ", "))
+	}
+	if len(changed) > 0 {
+		if _, err := repo.Run(append([]string{"add", "--"}, changed...)...); err != nil {
+			return nil, err
+		}
+		if _, err := repo.Run("commit", "--quiet", "-m", commitMessage(changed)); err != nil {
+			return nil, err
+		}
+		result.Committed = true
+	}
+
+	// Files checked out without the filter, e.g. by a plain git clone, are converted now
+	if config.Filter != "" {
+		if err := refreshFiles(repo, synced, changed); err != nil {
+			return nil, err
+		}
+	}
+
+	// Merge remote changes. The branch is fetched by name into FETCH_HEAD, which works for
+	// remote names and URLs alike.
+	var remote string
+	if remoteHead != "" {
+		if _, err := repo.Run("fetch", "--quiet", config.Remote, "refs/heads/"+branch); err != nil {
+			return nil, err
+		}
+		if remote, err = repo.Run("rev-parse", "FETCH_HEAD"); err != nil {
+			return nil, err
+		}
+		before, _ := repo.Run("rev-parse", "--verify", "--quiet", "HEAD")
+		if err := merge(repo, remote); err != nil {
+			return nil, err
+		}
+		after, _ := repo.Run("rev-parse", "HEAD")
+		result.Merged = before != after
+	}
+
+	// Push the result
+	if repo.HasCommits() {
+		local, _ := repo.Run("rev-parse", "HEAD")
+		if local != remote {
+			if _, err := repo.Run("push", "--quiet", config.Remote, "HEAD:refs/heads/"+branch); err != nil {
+				return nil, err
+			}
+			result.Pushed = true
+		}
+	}
+
+	return result, nil
+}
+
+// attributeOptions returns the git configuration assigning the merge driver and filter of the
+// config to the files, and a function removing the temporary attributes file
+func attributeOptions(files []string, config SyncConfig) ([]string, func(), error) {
+	var attributes, options []string
+	if config.MergeDriver != "" {
+		attributes = append(attributes, "merge=todotxt")
+		options = append(options,
+			"merge.todotxt.name=todo.txt merge driver of t",
+			"merge.todotxt.driver="+config.MergeDriver,
+		)
+	}
+	if config.Filter != "" {
+		attributes = append(attributes, "filter=todotxt")
+		options = append(options,
+			"filter.todotxt.clean="+config.Filter+" clean %f",
+			"filter.todotxt.smudge="+config.Filter+" smudge %f",
+			"filter.todotxt.required=true",
+		)
+	}
+	if len(attributes) == 0 {
+		return nil, func() {}, nil
+	}
+
+	var lines []string
+	for _, file := range files {
+		lines = append(lines, "/"+file+" "+strings.Join(attributes, " "))
+	}
+	file, err := os.CreateTemp("", "t-sync-git-attributes-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { os.Remove(file.Name()) }
+	_, err = file.WriteString(strings.Join(lines, "\n") + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return append(options, "core.attributesFile="+file.Name()), cleanup, nil
+}
+
+// refreshFiles writes the committed version of the unchanged files through the filter to the
+// working tree where it differs. The files are added again, which leaves their content in the
+// index unchanged but updates the file sizes git compares first.
+func refreshFiles(repo *Repo, files, changed []string) error {
+	isChanged := make(map[string]bool)
+	for _, file := range changed {
+		isChanged[file] = true
+	}
+	for _, file := range files {
+		if isChanged[file] {
+			continue
+		}
+		if _, err := repo.Run("rev-parse", "--verify", "--quiet", "HEAD:"+file); err != nil {
+			continue // Not committed yet
+		}
+		committed, err := repo.output("cat-file", "--filters", "HEAD:"+file)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(repo.Dir, filepath.FromSlash(file))
+		current, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if string(current) == committed {
+			continue
+		}
+		if err := os.WriteFile(path, []byte(committed), 0644); err != nil {
+			return err
+		}
+		if _, err := repo.Run("add", "--", file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// merge merges a commit into the checked out branch. Unrelated histories are merged as well,
+// as they occur when two machines start syncing into an empty remote at the same time.
+func merge(repo *Repo, commit string) error {
+	if _, err := repo.Run("merge", "--quiet", "--no-edit", "--allow-unrelated-histories", commit); err != nil {
+		if repo.HasRef("MERGE_HEAD") {
+			repo.Run("merge", "--abort")
+		}
+		return fmt.Errorf("merging %s failed, resolve it with git merge %s: %v", commit, commit, err)
+	}
+	return nil
+}
+
+// commitMessage generates the message for committing local changes
+func commitMessage(changed []string) string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown host"
+	}
+	return fmt.Sprintf("Update %s from %s", strings.Join(changed, ", "), host)
+}
@@ -0,0 +1,189 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"t/todo"
+)
+
+// TestMain makes the test binary act as merge driver if T_TEST_MERGE_DRIVER is set,
+// merging base, ours and theirs given as arguments like t merge-driver
+func TestMain(m *testing.M) {
+	if os.Getenv("T_TEST_MERGE_DRIVER") != "" {
+		result, err := todo.MergeFiles(os.Args[1], os.Args[2], os.Args[3])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if len(result.Conflicts) > 0 {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSync(t *testing.T) {
+	t.Setenv("GIT_AUTHOR_NAME", "t")
+	t.Setenv("GIT_AUTHOR_EMAIL", "t@example.org")
+	t.Setenv("GIT_COMMITTER_NAME", "t")
+	t.Setenv("GIT_COMMITTER_EMAIL", "t@example.org")
+
+	dir := t.TempDir()
+	remote := filepath.Join(dir, "remote.git")
+	runGit(t, dir, "init", "--quiet", "--bare", remote)
+
+	clone := func(name string) (*Repo, SyncConfig) {
+		path := filepath.Join(dir, name)
+		runGit(t, dir, "clone", "--quiet", remote, path)
+		runGit(t, path, "checkout", "--quiet", "-B", "main")
+		repo, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo, SyncConfig{
+			Files:  []string{filepath.Join(path, "todo.txt"), filepath.Join(path, "done.txt")},
+			Remote: "origin",
+			Branch: "main",
+		}
+	}
+
+	a, configA := clone("a")
+	writeFile(t, filepath.Join(a.Dir, "todo.txt"), "Write chapter\n")
+	result, err := Sync(a, configA)
+	if err != nil {
+		t.Fatalf("Sync() failed: %v", err)
+	}
+	if !result.Committed || result.Merged || !result.Pushed {
+		t.Errorf("first Sync() = %+v, want committed and pushed", result)
+	}
+
+	b, configB := clone("b")
+	writeFile(t, filepath.Join(b.Dir, "done.txt"), "x Buy milk\n")
+	if _, err := Sync(b, configB); err != nil {
+		t.Fatalf("Sync() failed: %v", err)
+	}
+
+	writeFile(t, filepath.Join(a.Dir, "todo.txt"), "Write chapter\nWrite abstract\n")
+	result, err = Sync(a, configA)
+	if err != nil {
+		t.Fatalf("Sync() failed: %v", err)
+	}
+	if !result.Committed || !result.Merged || !result.Pushed {
+		t.Errorf("Sync() = %+v, want committed, merged and pushed", result)
+	}
+	if _, err := os.Stat(filepath.Join(a.Dir, "done.txt")); err != nil {
+		t.Errorf("remote done.txt was not merged: %v", err)
+	}
+
+	// The remote can also be given by its path, an unreachable remote fails before committing
+	writeFile(t, filepath.Join(b.Dir, "done.txt"), "x Buy milk\nx Call mom\n")
+	configB.Remote = filepath.Join(dir, "missing.git")
+	if _, err := Sync(b, configB); err == nil {
+		t.Errorf("Sync() with missing remote succeeded")
+	}
+	if changes, _ := b.Changes(); len(changes) != 1 {
+		t.Errorf("Sync() with missing remote committed, changes = %v", changes)
+	}
+	configB.Remote = remote
+	result, err = Sync(b, configB)
+	if err != nil {
+		t.Fatalf("Sync() with remote path failed: %v", err)
+	}
+	if !result.Committed || !result.Merged || !result.Pushed {
+		t.Errorf("Sync() with remote path = %+v, want committed, merged and pushed", result)
+	}
+
+	writeFile(t, filepath.Join(b.Dir, "notes.md"), "unrelated\n")
+	if _, err := Sync(b, configB); err == nil || !strings.Contains(err.Error(), "notes.md") {
+		t.Errorf("Sync() with unrelated changes = %v, want error naming notes.md", err)
+	}
+}
+
+func TestSyncWithMergeDriver(t *testing.T) {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("T_TEST_MERGE_DRIVER", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "t")
+	t.Setenv("GIT_AUTHOR_EMAIL", "t@example.org")
+	t.Setenv("GIT_COMMITTER_NAME", "t")
+	t.Setenv("GIT_COMMITTER_EMAIL", "t@example.org")
+
+	dir := t.TempDir()
+	remote := filepath.Join(dir, "remote.git")
+	runGit(t, dir, "init", "--quiet", "--bare", remote)
+
+	clone := func(name string) (*Repo, SyncConfig) {
+		path := filepath.Join(dir, name)
+		runGit(t, dir, "clone", "--quiet", remote, path)
+		runGit(t, path, "checkout", "--quiet", "-B", "main")
+		repo, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo, SyncConfig{
+			Files:       []string{filepath.Join(path, "todo.txt")},
+			Remote:      "origin",
+			Branch:      "main",
+			MergeDriver: fmt.Sprintf("%q %%O %%A %%B", executable),
+		}
+	}
+
+	a, configA := clone("a")
+	writeFile(t, filepath.Join(a.Dir, "todo.txt"), "Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\nBuy milk id:tI4JeTHbMqlSrWPjtn3Zzf\n")
+	if _, err := Sync(a, configA); err != nil {
+		t.Fatalf("Sync() failed: %v", err)
+	}
+	b, configB := clone("b")
+	if _, err := Sync(b, configB); err != nil {
+		t.Fatalf("Sync() failed: %v", err)
+	}
+
+	// Both clones change different tasks on adjacent lines, which git alone cannot merge
+	writeFile(t, filepath.Join(a.Dir, "todo.txt"), "Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01\nBuy milk id:tI4JeTHbMqlSrWPjtn3Zzf\n")
+	if _, err := Sync(a, configA); err != nil {
+		t.Fatalf("Sync() failed: %v", err)
+	}
+	writeFile(t, filepath.Join(b.Dir, "todo.txt"), "Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\nx Buy milk id:tI4JeTHbMqlSrWPjtn3Zzf\n")
+	result, err := Sync(b, configB)
+	if err != nil {
+		t.Fatalf("Sync() failed: %v", err)
+	}
+	if !result.Committed || !result.Merged || !result.Pushed {
+		t.Errorf("Sync() = %+v, want committed, merged and pushed", result)
+	}
+
+	want := "Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01\nx Buy milk id:tI4JeTHbMqlSrWPjtn3Zzf\n"
+	content, _ := os.ReadFile(filepath.Join(b.Dir, "todo.txt"))
+	if string(content) != want {
+		t.Errorf("merged todo.txt = %q, want %q", content, want)
+	}
+	if _, err := Sync(a, configA); err != nil {
+		t.Fatalf("Sync() failed: %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(a.Dir, "todo.txt")); string(content) != want {
+		t.Errorf("todo.txt of the other clone = %q, want %q", content, want)
+	}
+}
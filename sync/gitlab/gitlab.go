@@ -8,6 +8,7 @@ import (
 	"time"
 
 	todo "github.com/1set/todotxt"
+	"t/utils"
 )
 // Issue represents a GitLab issue
 type Issue struct {
@@ -16,6 +17,20 @@ type Issue struct {
 	State     string     `json:"state"`
 	CreatedAt time.Time  `json:"created_at"`
 	DueDate   *time.Time `json:"due_date"`
+	TimeStats TimeStats  `json:"time_stats"`
+}
+
+// TimeStats represents the time tracking statistics of a GitLab issue or merge request
+type TimeStats struct {
+	TimeEstimate   int `json:"time_estimate"`    // in seconds
+	TotalTimeSpent int `json:"total_time_spent"` // in seconds
+}
+
+// setEstimateTag sets the est tag of a task from the GitLab time estimate, if there is one
+func setEstimateTag(task *todo.Task, stats TimeStats) {
+	if stats.TimeEstimate > 0 {
+		task.AdditionalTags["est"] = utils.FormatEstimate(time.Duration(stats.TimeEstimate) * time.Second)
+	}
 }
 // GetUserIssues fetches issues assigned to a user from GitLab and returns them
 func GetUserIssues(token, baseURL, endpoint string) ([]Issue, error) {
@@ -76,6 +91,7 @@ func CreateIssueTaskList(issues []Issue, prefix string) todo.TaskList {
 		}
 		task.AdditionalTags["url"] = issue.WebURL
 		task.AdditionalTags["state"] = issue.State
+		setEstimateTag(&task, issue.TimeStats)
 
 		tl.AddTask(&task)
 	}
@@ -91,6 +107,7 @@ type MergeRequest struct {
 	MergedAt    *time.Time `json:"merged_at"`
 	SourceBranch string    `json:"source_branch"`
 	TargetBranch string    `json:"target_branch"`
+	TimeStats    TimeStats `json:"time_stats"`
 }
 
 // GetUserMergeRequests fetches merge requests assigned to a user from GitLab and returns them
@@ -161,6 +178,7 @@ func CreateMergeRequestTaskList(mergeRequests []MergeRequest, prefix string) tod
 		task.AdditionalTags["state"] = mr.State
 		task.AdditionalTags["source_branch"] = mr.SourceBranch
 		task.AdditionalTags["target_branch"] = mr.TargetBranch
+		setEstimateTag(&task, mr.TimeStats)
 
 		tl.AddTask(&task)
 	}
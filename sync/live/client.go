@@ -0,0 +1,218 @@
+package live
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"t/todo"
+)
+
+// maxReconnectDelay limits the delay between reconnection attempts
+const maxReconnectDelay = 30 * time.Second
+
+// Client keeps a replica in sync with a server
+type Client struct {
+	URL      string // WebSocket URL of the server, e.g. ws://example.org:8080/live
+	Replica  *Replica
+	Interval time.Duration // How often the todo file is checked for local changes
+	Logf     func(format string, args ...interface{})
+}
+
+// NewClient creates a client syncing the replica with the server at serverURL
+func NewClient(serverURL string, r *Replica) *Client {
+	return &Client{URL: serverURL, Replica: r, Interval: time.Second, Logf: log.Printf}
+}
+
+// Run syncs until ctx is done, reconnecting with increasing delays when the connection is lost.
+// After reconnecting, changes missed in the meantime are exchanged.
+func (c *Client) Run(ctx context.Context) {
+	delay := time.Second
+	for {
+		connected, err := c.session(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			delay = time.Second
+		}
+		c.Logf("Connection to %s lost: %v, reconnecting in %v", c.URL, err, delay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// session syncs over a single connection until it fails or ctx is done
+func (c *Client) session(ctx context.Context) (connected bool, err error) {
+	origin, err := originOf(c.URL)
+	if err != nil {
+		return false, err
+	}
+	ws, err := websocket.Dial(c.URL, "", origin)
+	if err != nil {
+		return false, err
+	}
+	defer ws.Close()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			ws.Close()
+		case <-stop:
+		}
+	}()
+
+	r := c.Replica
+	if err := websocket.JSON.Send(ws, Message{Type: TypeHello, Node: r.Node, Server: r.Server, Since: r.Seq}); err != nil {
+		return false, err
+	}
+	var welcome Message
+	if err := websocket.JSON.Receive(ws, &welcome); err != nil {
+		return false, err
+	}
+	if welcome.Type != TypeWelcome {
+		return false, fmt.Errorf("unexpected %s message from server", welcome.Type)
+	}
+	if welcome.Server != r.Server {
+		// A different server sends all tasks and gets all of ours, so it can merge them
+		r.Server, r.Seq = welcome.Server, 0
+		for _, entry := range r.Tasks {
+			entry.Pending = true
+		}
+	}
+	c.Logf("Connected to %s", c.URL)
+
+	messages := make(chan Message)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			var m Message
+			if err := websocket.JSON.Receive(ws, &m); err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case messages <- m:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	// Send changes made while disconnected, and those the server did not confirm
+	if err := c.sendChanges(ws, true); err != nil {
+		return true, err
+	}
+
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-errs:
+			return true, err
+		case <-ticker.C:
+			if err := c.sendChanges(ws, false); err != nil {
+				return true, err
+			}
+		case m := <-messages:
+			// Local changes first, so they are not overwritten
+			if err := c.sendChanges(ws, false); err != nil {
+				return true, err
+			}
+			if err := c.receive(m); err != nil {
+				return true, err
+			}
+		}
+	}
+}
+
+// sendChanges sends the local changes of the todo file, or all unconfirmed changes if pending is set
+func (c *Client) sendChanges(ws *websocket.Conn, pending bool) error {
+	r := c.Replica
+	fileLock, err := todo.LockFile(r.path)
+	if err != nil {
+		return err
+	}
+	ops, err := r.Scan()
+	fileLock.Unlock()
+	if err != nil {
+		return err
+	}
+	for _, op := range ops {
+		r.Tasks[op.ID].Pending = true
+	}
+	if pending {
+		ops = ops[:0]
+		for id, entry := range r.Tasks {
+			if entry.Pending {
+				ops = append(ops, entry.op(id))
+			}
+		}
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+
+	if err := r.Save(); err != nil {
+		return err
+	}
+	for i := range ops {
+		if err := websocket.JSON.Send(ws, Message{Type: TypeOp, Op: &ops[i]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// receive applies an op from the server
+func (c *Client) receive(m Message) error {
+	if m.Type != TypeOp || m.Op == nil {
+		return nil
+	}
+	r := c.Replica
+	fileLock, err := todo.LockFile(r.path)
+	if err != nil {
+		return err
+	}
+	_, _, err = r.Apply(*m.Op, false)
+	fileLock.Unlock()
+	if err != nil {
+		return err
+	}
+	// The server has a local change once it sends a version including it
+	if entry := r.Tasks[m.Op.ID]; entry != nil && entry.Pending {
+		if order := entry.Version.Compare(m.Op.Version); order == Equal || order == Before {
+			entry.Pending = false
+		}
+	}
+	if m.Seq > r.Seq {
+		r.Seq = m.Seq
+	}
+	return r.Save()
+}
+
+// originOf returns the HTTP origin of a WebSocket URL, as required by the handshake
+func originOf(wsURL string) (string, error) {
+	u, err := url.Parse(wsURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %s: %v", wsURL, err)
+	}
+	scheme := "http"
+	if u.Scheme == "wss" {
+		scheme = "https"
+	}
+	return scheme + "://" + u.Host, nil
+}
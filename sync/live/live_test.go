@@ -0,0 +1,281 @@
+package live
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adrg/xdg"
+)
+
+func TestVersionCompare(t *testing.T) {
+	tests := []struct {
+		a, b Version
+		want Order
+	}{
+		{Version{}, Version{}, Equal},
+		{Version{"a": 1}, Version{"a": 1, "b": 0}, Equal},
+		{Version{"a": 1}, Version{"a": 2}, Before},
+		{Version{"a": 1, "b": 1}, Version{"a": 1}, After},
+		{Version{"a": 2}, Version{"a": 1, "b": 1}, Concurrent},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Compare(tt.b); got != tt.want {
+			t.Errorf("%v.Compare(%v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func quiet(string, ...interface{}) {}
+
+// startClient syncs the todo file at path with the server until the returned function is called
+func startClient(t *testing.T, url, path string) func() {
+	t.Helper()
+	r, err := LoadReplica(url+" "+path, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(url, r)
+	c.Interval, c.Logf = 10*time.Millisecond, quiet
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.Run(ctx)
+		close(done)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// waitFor waits until the file at path contains all wanted lines and none of the unwanted
+func waitFor(t *testing.T, path string, want []string, unwanted ...string) {
+	t.Helper()
+	var content string
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		bs, _ := os.ReadFile(path)
+		content = string(bs)
+		ok := true
+		for _, line := range want {
+			ok = ok && strings.Contains(content, line)
+		}
+		for _, line := range unwanted {
+			ok = ok && !strings.Contains(content, line)
+		}
+		if ok {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("%s = %q, want lines %q and not %q", filepath.Base(path), content, want, unwanted)
+}
+
+func TestLiveSync(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	xdg.Reload()
+
+	dir := t.TempDir()
+	serverFile := filepath.Join(dir, "server.txt")
+	aFile, bFile := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+	os.WriteFile(serverFile, []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\n"), 0640)
+
+	r, err := LoadReplica("server", serverFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(r)
+	server.Interval, server.Logf = 10*time.Millisecond, quiet
+	ctx, cancel := context.WithCancel(context.Background())
+	watched := make(chan struct{})
+	go func() {
+		server.Watch(ctx)
+		close(watched)
+	}()
+	defer func() {
+		cancel()
+		<-watched
+	}()
+	httpServer := httptest.NewServer(server.Handler())
+	defer httpServer.Close()
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	// New clients catch up with the server
+	stopA := startClient(t, url, aFile)
+	defer func() { stopA() }()
+	stopB := startClient(t, url, bFile)
+	waitFor(t, aFile, []string{"Write chapter"})
+	waitFor(t, bFile, []string{"Write chapter"})
+
+	// Changes of a client reach the server and the other client
+	os.WriteFile(aFile, []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01\nBuy milk\n"), 0640)
+	waitFor(t, bFile, []string{"due:2024-11-01", "Buy milk id:"})
+	waitFor(t, serverFile, []string{"due:2024-11-01", "Buy milk id:"})
+
+	// Changes made while disconnected are exchanged after reconnecting
+	stopB()
+	content, _ := os.ReadFile(bFile)
+	os.WriteFile(bFile, []byte(strings.Replace(string(content), "Buy milk", "x Buy milk", 1)), 0640)
+	os.WriteFile(serverFile, []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01 +book\n"+strings.Split(string(content), "\n")[1]+"\n"), 0640)
+	waitFor(t, aFile, []string{"+book"})
+
+	stopB = startClient(t, url, bFile)
+	defer func() { stopB() }()
+	waitFor(t, bFile, []string{"+book", "x Buy milk"})
+	waitFor(t, aFile, []string{"+book", "x Buy milk"})
+	waitFor(t, serverFile, []string{"+book", "x Buy milk"})
+
+	// Deletions are synced
+	os.WriteFile(aFile, []byte("Call mom\n"), 0640)
+	waitFor(t, bFile, []string{"Call mom"}, "Write chapter", "Buy milk")
+	waitFor(t, serverFile, []string{"Call mom"}, "Write chapter", "Buy milk")
+}
+
+func TestConcurrentChanges(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	xdg.Reload()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "todo.txt")
+	os.WriteFile(path, []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-10-30\n"), 0640)
+	r, err := LoadReplica("server", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops, err := r.Scan()
+	if err != nil || len(ops) != 1 {
+		t.Fatalf("Scan() = %v, %v, want one op", ops, err)
+	}
+	base := ops[0]
+	r.Record(base)
+
+	// Two nodes change different fields of the broadcast version, both changes are kept
+	a := Op{ID: base.ID, Task: "Write chapter due:2024-11-01 id:tI4JeTHbMqhXUS9Ig0Pg9t", Version: base.Version.Merge(Version{"a": 1})}
+	b := Op{ID: base.ID, Task: "(A) Write chapter due:2024-10-30 id:tI4JeTHbMqhXUS9Ig0Pg9t", Version: base.Version.Merge(Version{"b": 1})}
+	result, _, err := r.Apply(a, true)
+	if err != nil || result == nil {
+		t.Fatalf("Apply(a) = %v, %v", result, err)
+	}
+	r.Record(*result)
+	result, conflicts, err := r.Apply(b, true)
+	if err != nil || result == nil {
+		t.Fatalf("Apply(b) = %v, %v", result, err)
+	}
+	r.Record(*result)
+	if result.Version.Compare(a.Version) != After || result.Version.Compare(b.Version) != After {
+		t.Errorf("merged version %v does not supersede %v and %v", result.Version, a.Version, b.Version)
+	}
+	if want := "(A) Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01"; result.Task != want {
+		t.Errorf("merged task = %q, want %q", result.Task, want)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("Apply(b) reported conflicts %v, want none", conflicts)
+	}
+
+	// A field changed differently on both sides keeps the server's value
+	d := Op{ID: base.ID, Task: "Write chapter due:2024-11-02 id:tI4JeTHbMqhXUS9Ig0Pg9t", Version: base.Version.Merge(Version{"d": 1})}
+	result, conflicts, err = r.Apply(d, true)
+	if err != nil || result == nil {
+		t.Fatalf("Apply(d) = %v, %v", result, err)
+	}
+	if want := "(A) Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01"; result.Task != want {
+		t.Errorf("merged task = %q, want %q", result.Task, want)
+	}
+	if len(conflicts) != 1 || conflicts[0].Field != "due" {
+		t.Errorf("Apply(d) reported conflicts %v, want due", conflicts)
+	}
+
+	// A concurrent deletion loses against the change
+	deletion := Op{ID: base.ID, Deleted: true, Version: base.Version.Merge(Version{"c": 1})}
+	if result, _, err := r.Apply(deletion, true); err != nil || result == nil || result.Deleted {
+		t.Errorf("Apply(deletion) = %v, %v, want the task to be kept", result, err)
+	}
+
+	// Outdated ops are ignored
+	if result, _, err := r.Apply(a, true); err != nil || result != nil {
+		t.Errorf("Apply(outdated) = %v, %v, want nil", result, err)
+	}
+}
+
+func TestScanDuplicateIDs(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	xdg.Reload()
+
+	path := filepath.Join(t.TempDir(), "todo.txt")
+	os.WriteFile(path, []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\nWrite appendix id:tI4JeTHbMqhXUS9Ig0Pg9t\n"), 0640)
+	r, err := LoadReplica("server", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The copied task gets an id of its own instead of being dropped
+	ops, err := r.Scan()
+	if err != nil || len(ops) != 2 {
+		t.Fatalf("Scan() = %v, %v, want two ops", ops, err)
+	}
+	if ops[0].ID != "tI4JeTHbMqhXUS9Ig0Pg9t" || ops[1].ID == ops[0].ID {
+		t.Errorf("Scan() ids = %s, %s, want the original and a new id", ops[0].ID, ops[1].ID)
+	}
+	content, _ := os.ReadFile(path)
+	if !strings.Contains(string(content), "Write appendix id:"+ops[1].ID) {
+		t.Errorf("todo file = %q, want the copy with its new id", content)
+	}
+
+	// Changing the task leaves its former duplicate alone
+	change := Op{ID: ops[0].ID, Task: "(A) Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t", Version: ops[0].Version.Merge(Version{"a": 1})}
+	if _, _, err := r.Apply(change, true); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, path, []string{"(A) Write chapter", "Write appendix"})
+}
+
+func TestServerChange(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	xdg.Reload()
+
+	dir := t.TempDir()
+	aFile := filepath.Join(dir, "a.txt")
+	var mu sync.Mutex
+	var server *Server
+	startServer := func(location, content string) string {
+		path := filepath.Join(dir, location+".txt")
+		os.WriteFile(path, []byte(content), 0640)
+		r, err := LoadReplica(location, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		server = NewServer(r)
+		server.Logf = quiet
+		return path
+	}
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		handler := server.Handler()
+		mu.Unlock()
+		handler.ServeHTTP(w, r)
+	}))
+	defer httpServer.Close()
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	startServer("server", "Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\n")
+	stop := startClient(t, url, aFile)
+	waitFor(t, aFile, []string{"Write chapter"})
+	stop()
+
+	// A server that lost its state gets all tasks of the client
+	serverFile := startServer("new server", "Buy milk id:tI4JeTHbMqlSrWPjtn3Zzf\n")
+	stop = startClient(t, url, aFile)
+	defer stop()
+	waitFor(t, serverFile, []string{"Write chapter", "Buy milk"})
+	waitFor(t, aFile, []string{"Write chapter", "Buy milk"})
+}
@@ -0,0 +1,124 @@
+// Package live implements the t live sync protocol, which keeps todo files on several machines
+// in sync over WebSocket connections to a server holding the canonical task list.
+//
+// # Protocol
+//
+// Every WebSocket text frame carries one JSON encoded Message. Tasks are identified by their id or
+// uuid tag, always sent in the short id form. Each task has a version vector counting the changes
+// to it per node, so replicas can tell whether a change supersedes theirs or was made concurrently.
+//
+// A session starts with the client introducing itself:
+//
+//	{"type": "hello", "node": "<client node id>", "server": "<server id>", "since": 42}
+//
+// server and since are the server id and the highest sequence number the client has seen, both
+// empty for a new client. The server answers with
+//
+//	{"type": "welcome", "server": "<server id>"}
+//
+// followed by an op message for every task that changed after since, in sequence order. If the
+// server id does not match, e.g. because the server lost its state, all tasks are sent, and the
+// client sends all of its tasks as well.
+//
+// Afterwards both sides send op messages whenever a task changes:
+//
+//	{"type": "op", "seq": 43, "op": {"id": "<short id>", "task": "<todo.txt line>", "version": {"<node>": 3}}}
+//	{"type": "op", "seq": 44, "op": {"id": "<short id>", "deleted": true, "version": {"<node>": 4}}}
+//
+// Ops from the server carry the sequence number the server assigned to the change. Clients send
+// ops for their local changes without one, and resend unconfirmed changes after reconnecting.
+//
+// The server accepts an op if its version is newer than the server's version of the task. If both
+// were changed concurrently, the server merges them like t todo merge, with the newest version it
+// broadcast that the op's version descends from as common ancestor: a field changed on one side
+// takes that change, a field changed differently takes the value of the task with the newer
+// modified tag, or keeps the server's value, and a change wins over a deletion. The result is sent with a version
+// covering both. Every change the server accepts or makes is broadcast to all clients, including
+// the sender. Clients apply ops that are at least as new as their version of the task and leave
+// concurrent ones to the server.
+package live
+
+// Message types
+const (
+	TypeHello   = "hello"
+	TypeWelcome = "welcome"
+	TypeOp      = "op"
+)
+
+// Message is a message of the live sync protocol
+type Message struct {
+	Type   string `json:"type"`
+	Node   string `json:"node,omitempty"`   // hello: node id of the client
+	Server string `json:"server,omitempty"` // hello, welcome: id of the server
+	Since  uint64 `json:"since,omitempty"`  // hello: highest sequence number the client has seen
+	Seq    uint64 `json:"seq,omitempty"`    // op: sequence number assigned by the server
+	Op     *Op    `json:"op,omitempty"`
+}
+
+// Op is a change of a single task
+type Op struct {
+	ID      string  `json:"id"`
+	Task    string  `json:"task,omitempty"` // The task as todo.txt line, empty for deletions
+	Deleted bool    `json:"deleted,omitempty"`
+	Version Version `json:"version"`
+}
+
+// Version is a version vector, counting the changes to a task per node
+type Version map[string]uint64
+
+// Order is the result of comparing two versions
+type Order int
+
+const (
+	Equal      Order = iota
+	Before           // All changes of the version are known to the other version
+	After            // The version contains all changes of the other version
+	Concurrent       // Both versions contain changes unknown to the other one
+)
+
+// Compare compares the version with another version
+func (v Version) Compare(other Version) Order {
+	before, after := false, false
+	for node, n := range v {
+		if n > other[node] {
+			after = true
+		} else if n < other[node] {
+			before = true
+		}
+	}
+	for node, n := range other {
+		if _, ok := v[node]; !ok && n > 0 {
+			before = true
+		}
+	}
+
+	switch {
+	case before && after:
+		return Concurrent
+	case before:
+		return Before
+	case after:
+		return After
+	}
+	return Equal
+}
+
+// Merge returns a version containing the changes of both versions
+func (v Version) Merge(other Version) Version {
+	merged := v.Copy()
+	for node, n := range other {
+		if n > merged[node] {
+			merged[node] = n
+		}
+	}
+	return merged
+}
+
+// Copy returns a copy of the version
+func (v Version) Copy() Version {
+	c := make(Version, len(v))
+	for node, n := range v {
+		c[node] = n
+	}
+	return c
+}
@@ -0,0 +1,321 @@
+package live
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	todotxt "github.com/1set/todotxt"
+	"github.com/adrg/xdg"
+
+	"t/todo"
+	"t/utils"
+)
+
+// Entry is the last known version of a task
+type Entry struct {
+	Task       string      `json:"task,omitempty"`
+	Deleted    bool        `json:"deleted,omitempty"`
+	Version    Version     `json:"version"`
+	Seq        uint64      `json:"seq,omitempty"`        // Server: sequence number of the last change
+	Broadcasts []Broadcast `json:"broadcasts,omitempty"` // Server: last versions sent to the clients, oldest first
+	Pending    bool        `json:"pending,omitempty"`    // Client: local change not yet confirmed by the server
+}
+
+// Broadcast is a version of a task the server sent to the clients. Clients base their changes
+// on these versions, so the server merges concurrent changes with them as common ancestor.
+type Broadcast struct {
+	Task    string  `json:"task,omitempty"`
+	Deleted bool    `json:"deleted,omitempty"`
+	Version Version `json:"version"`
+}
+
+// maxBroadcasts limits the versions kept per task as merge bases
+const maxBroadcasts = 8
+
+// Replica is a todo file taking part in live sync, together with the versions of its tasks.
+// The versions are kept in the XDG state directory.
+type Replica struct {
+	Node   string            `json:"node"`             // Node id of this replica
+	Server string            `json:"server,omitempty"` // Server id the sequence number belongs to
+	Seq    uint64            `json:"seq,omitempty"`    // Server: last assigned, client: last seen sequence number
+	Tasks  map[string]*Entry `json:"tasks"`
+
+	path      string // Path of the todo file
+	statePath string
+}
+
+// LoadReplica loads the live sync state of the todo file at path for a location,
+// e.g. the URL of the server. A new node id is created if there is no state yet.
+func LoadReplica(location, path string) (*Replica, error) {
+	sum := sha256.Sum256([]byte(location))
+	statePath, err := xdg.StateFile(filepath.Join("t", "sync", "live", hex.EncodeToString(sum[:8])+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("error locating sync state: %v", err)
+	}
+
+	r := &Replica{path: path, statePath: statePath}
+	bs, err := os.ReadFile(statePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error reading sync state: %v", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(bs, r); err != nil {
+			return nil, fmt.Errorf("error unmarshaling sync state %s: %v", statePath, err)
+		}
+	}
+
+	if r.Node == "" {
+		r.Node = utils.ShortEncodeUUID(utils.NewUUID())
+	}
+	if r.Tasks == nil {
+		r.Tasks = make(map[string]*Entry)
+	}
+	return r, nil
+}
+
+// Save writes the sync state
+func (r *Replica) Save() error {
+	bs, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling sync state: %v", err)
+	}
+	if err := os.WriteFile(r.statePath, bs, 0600); err != nil {
+		return fmt.Errorf("error writing sync state: %v", err)
+	}
+	return nil
+}
+
+// Scan compares the todo file with the known versions of its tasks and returns an op for every
+// task that was added, changed or removed locally. Tasks without id get one, as do later copies
+// of a task with the same id, e.g. from copying a line.
+func (r *Replica) Scan() ([]Op, error) {
+	taskList, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+
+	// Every task needs an id of its own to be synced
+	seen := make(map[string]bool)
+	changedIDs := false
+	for i := range taskList {
+		task := &taskList[i]
+		if id := taskID(task); id == "" || seen[id] {
+			delete(task.AdditionalTags, "id")
+			delete(task.AdditionalTags, "uuid")
+			todo.EnsureTaskProperties(task, todo.TaskEnsureConfig{PreferShortIDs: true})
+			changedIDs = true
+		}
+		seen[taskID(task)] = true
+	}
+	if changedIDs {
+		if err := todo.WriteTodoFile(taskList, r.path); err != nil {
+			return nil, err
+		}
+	}
+
+	var ops []Op
+	for i := range taskList {
+		id := taskID(&taskList[i])
+		line := taskList[i].String()
+		entry, known := r.Tasks[id]
+		if known && !entry.Deleted && entry.Task == line {
+			continue
+		}
+		if !known {
+			entry = &Entry{Version: Version{}}
+			r.Tasks[id] = entry
+		}
+		entry.Task, entry.Deleted = line, false
+		ops = append(ops, r.change(id, entry))
+	}
+
+	for id, entry := range r.Tasks {
+		if !entry.Deleted && !seen[id] {
+			entry.Task, entry.Deleted = "", true
+			ops = append(ops, r.change(id, entry))
+		}
+	}
+	return ops, nil
+}
+
+// change counts a local change of a task and returns the op for it
+func (r *Replica) change(id string, entry *Entry) Op {
+	entry.Version = entry.Version.Copy()
+	entry.Version[r.Node]++
+	return entry.op(id)
+}
+
+// Apply applies an op to the replica and the todo file if it is newer than the known version of
+// the task. Concurrent changes are merged if merge is set and ignored otherwise. It returns the
+// resulting op, or nil if nothing changed.
+func (r *Replica) Apply(op Op, merge bool) (*Op, []todo.MergeConflict, error) {
+	entry, known := r.Tasks[op.ID]
+	var conflicts []todo.MergeConflict
+	if !known {
+		entry = &Entry{Version: Version{}}
+	}
+
+	switch entry.Version.Compare(op.Version) {
+	case Equal, After:
+		return nil, nil, nil
+	case Before:
+		entry.Task, entry.Deleted = "", op.Deleted
+		if !op.Deleted {
+			task, err := todotxt.ParseTask(op.Task)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid task in op for %s: %v", op.ID, err)
+			}
+			entry.Task = task.String()
+		}
+		entry.Version = op.Version.Copy()
+	case Concurrent:
+		if !merge {
+			return nil, nil, nil
+		}
+		merged, mergeConflicts, err := mergeEntries(entry.base(op.Version), entry, op)
+		if err != nil {
+			return nil, nil, err
+		}
+		conflicts = mergeConflicts
+		entry.Task, entry.Deleted = merged, merged == ""
+		entry.Version = entry.Version.Merge(op.Version)
+		entry.Version[r.Node]++
+	}
+
+	r.Tasks[op.ID] = entry
+	if err := r.write(op.ID, entry); err != nil {
+		return nil, nil, err
+	}
+	result := entry.op(op.ID)
+	return &result, conflicts, nil
+}
+
+// mergeEntries merges the concurrent versions of a task, with their common ancestor if base is
+// not nil. A change wins over a deletion.
+func mergeEntries(base *Broadcast, entry *Entry, op Op) (string, []todo.MergeConflict, error) {
+	switch {
+	case entry.Deleted && op.Deleted:
+		return "", nil, nil
+	case op.Deleted:
+		return entry.Task, nil, nil
+	}
+
+	theirs, err := todotxt.ParseTask(op.Task)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid task in op for %s: %v", op.ID, err)
+	}
+	if entry.Deleted {
+		return theirs.String(), nil, nil
+	}
+	ours, err := todotxt.ParseTask(entry.Task)
+	if err != nil {
+		return "", nil, err
+	}
+	if base == nil || base.Deleted {
+		merged, conflicts := todo.MergeTask(ours, theirs)
+		return merged.String(), conflicts, nil
+	}
+	ancestor, err := todotxt.ParseTask(base.Task)
+	if err != nil {
+		return "", nil, err
+	}
+	merged, conflicts := todo.MergeTaskWithBase(ancestor, ours, theirs)
+	return merged.String(), conflicts, nil
+}
+
+// base returns the newest broadcast version the given version descends from, or nil
+func (e *Entry) base(version Version) *Broadcast {
+	for i := len(e.Broadcasts) - 1; i >= 0; i-- {
+		if order := e.Broadcasts[i].Version.Compare(version); order == Equal || order == Before {
+			return &e.Broadcasts[i]
+		}
+	}
+	return nil
+}
+
+// Record assigns the next sequence number to the change of a task and keeps its version as base
+// for merging later concurrent changes
+func (r *Replica) Record(op Op) Message {
+	r.Seq++
+	entry := r.Tasks[op.ID]
+	entry.Seq = r.Seq
+	entry.Broadcasts = append(entry.Broadcasts, Broadcast{Task: op.Task, Deleted: op.Deleted, Version: op.Version.Copy()})
+	if len(entry.Broadcasts) > maxBroadcasts {
+		entry.Broadcasts = entry.Broadcasts[len(entry.Broadcasts)-maxBroadcasts:]
+	}
+	return Message{Type: TypeOp, Seq: r.Seq, Op: &op}
+}
+
+// Since returns op messages for all tasks changed after the sequence number, in sequence order
+func (r *Replica) Since(seq uint64) []Message {
+	var messages []Message
+	for id, entry := range r.Tasks {
+		if entry.Seq > seq {
+			op := entry.op(id)
+			messages = append(messages, Message{Type: TypeOp, Seq: entry.Seq, Op: &op})
+		}
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].Seq < messages[j].Seq })
+	return messages
+}
+
+func (e *Entry) op(id string) Op {
+	return Op{ID: id, Task: e.Task, Deleted: e.Deleted, Version: e.Version.Copy()}
+}
+
+// read reads the todo file, a missing file is an empty list
+func (r *Replica) read() (todotxt.TaskList, error) {
+	taskList, err := todo.ReadTodoFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return todotxt.NewTaskList(), nil
+	}
+	return taskList, err
+}
+
+// write replaces, adds or removes a task in the todo file. Only the first task with the id is
+// changed, Scan gives further copies an id of their own.
+func (r *Replica) write(id string, entry *Entry) error {
+	taskList, err := r.read()
+	if err != nil {
+		return err
+	}
+
+	var task *todotxt.Task
+	if !entry.Deleted {
+		if task, err = todotxt.ParseTask(entry.Task); err != nil {
+			return err
+		}
+	}
+
+	updated := todotxt.NewTaskList()
+	found := false
+	for i := range taskList {
+		if found || taskID(&taskList[i]) != id {
+			updated.AddTask(&taskList[i])
+			continue
+		}
+		if task != nil {
+			updated.AddTask(task)
+		}
+		found = true
+	}
+	if !found && task != nil {
+		updated.AddTask(task)
+	}
+	return todo.WriteTodoFile(updated, r.path)
+}
+
+// taskID returns the id of a task in short form, or an empty string if it has none
+func taskID(task *todotxt.Task) string {
+	id, ok := todo.TaskIdentifier(task)
+	if !ok {
+		return ""
+	}
+	return utils.ShortEncodeUUID(id)
+}
@@ -0,0 +1,201 @@
+package live
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"t/todo"
+)
+
+// sendBuffer is the number of messages queued for a client before it is disconnected as too slow
+const sendBuffer = 256
+
+// Server holds the canonical task list and relays changes between the connected clients
+type Server struct {
+	Replica  *Replica
+	Interval time.Duration // How often the todo file is checked for local changes
+	Logf     func(format string, args ...interface{})
+
+	mu       sync.Mutex
+	fileLock *todo.FileLock
+	clients  map[*peer]bool
+}
+
+type peer struct {
+	node string
+	send chan Message
+}
+
+// NewServer creates a server for the replica. The node id of the replica serves as server id.
+func NewServer(r *Replica) *Server {
+	r.Server = r.Node
+	return &Server{Replica: r, Interval: time.Second, Logf: log.Printf, clients: make(map[*peer]bool)}
+}
+
+// Handler returns the WebSocket handler clients connect to
+func (s *Server) Handler() http.Handler {
+	return websocket.Handler(s.serve)
+}
+
+// Lock locks the todo file against changes by clients and other processes, so other code can
+// change it safely
+func (s *Server) Lock() {
+	s.lock()
+}
+
+// Unlock sends the changes made to the todo file while locked to the clients and unlocks it
+func (s *Server) Unlock() {
+	s.scan()
+	s.unlock()
+}
+
+// lock takes s.mu and the file lock of the todo file
+func (s *Server) lock() {
+	s.mu.Lock()
+	fileLock, err := todo.LockFile(s.Replica.path)
+	if err != nil {
+		s.Logf("%v", err)
+	}
+	s.fileLock = fileLock
+}
+
+func (s *Server) unlock() {
+	if s.fileLock != nil {
+		s.fileLock.Unlock()
+		s.fileLock = nil
+	}
+	s.mu.Unlock()
+}
+
+// Watch checks the todo file for local changes until ctx is done
+func (s *Server) Watch(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.lock()
+			s.scan()
+			s.unlock()
+		}
+	}
+}
+
+// scan sends local changes of the todo file to the clients. The caller must hold the lock.
+func (s *Server) scan() {
+	ops, err := s.Replica.Scan()
+	if err != nil {
+		s.Logf("Error reading todo file: %v", err)
+		return
+	}
+	for _, op := range ops {
+		s.broadcast(s.Replica.Record(op))
+	}
+	if len(ops) > 0 {
+		s.save()
+	}
+}
+
+func (s *Server) serve(ws *websocket.Conn) {
+	defer ws.Close()
+
+	var hello Message
+	if err := websocket.JSON.Receive(ws, &hello); err != nil || hello.Type != TypeHello {
+		return
+	}
+
+	s.lock()
+	s.scan()
+	since := hello.Since
+	if hello.Server != s.Replica.Server {
+		since = 0
+	}
+	catchUp := s.Replica.Since(since)
+	p := &peer{node: hello.Node, send: make(chan Message, len(catchUp)+1+sendBuffer)}
+	p.send <- Message{Type: TypeWelcome, Server: s.Replica.Server}
+	for _, m := range catchUp {
+		p.send <- m
+	}
+	s.clients[p] = true
+	s.unlock()
+	s.Logf("Client %s connected, sending %d changes", p.node, len(catchUp))
+
+	go func() {
+		for m := range p.send {
+			if err := websocket.JSON.Send(ws, m); err != nil {
+				break
+			}
+		}
+		ws.Close()
+	}()
+
+	for {
+		var m Message
+		if err := websocket.JSON.Receive(ws, &m); err != nil {
+			break
+		}
+		if m.Type != TypeOp || m.Op == nil || m.Op.ID == "" {
+			continue
+		}
+		s.receive(*m.Op)
+	}
+
+	s.mu.Lock()
+	s.remove(p)
+	s.mu.Unlock()
+	s.Logf("Client %s disconnected", p.node)
+}
+
+// receive applies an op from a client and broadcasts the resulting change
+func (s *Server) receive(op Op) {
+	s.lock()
+	defer s.unlock()
+
+	s.scan()
+	result, conflicts, err := s.Replica.Apply(op, true)
+	if err != nil {
+		s.Logf("Error applying change of %s: %v", op.ID, err)
+		return
+	}
+	for _, conflict := range conflicts {
+		s.Logf("Conflict in %s: %s, kept %q over %q", conflict.Task, conflict.Field, conflict.Ours, conflict.Theirs)
+	}
+	if result != nil {
+		s.broadcast(s.Replica.Record(*result))
+		s.save()
+	}
+}
+
+// broadcast queues a message for all clients, disconnecting those that do not keep up.
+// The caller must hold s.mu.
+func (s *Server) broadcast(m Message) {
+	for p := range s.clients {
+		select {
+		case p.send <- m:
+		default:
+			s.Logf("Client %s is too slow, disconnecting", p.node)
+			s.remove(p)
+		}
+	}
+}
+
+// remove unregisters a client, the caller must hold s.mu
+func (s *Server) remove(p *peer) {
+	if s.clients[p] {
+		delete(s.clients, p)
+		close(p.send)
+	}
+}
+
+func (s *Server) save() {
+	if err := s.Replica.Save(); err != nil {
+		s.Logf("%v", err)
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
+	"strconv"
 	"time"
 	"strings"
 
 	todo "github.com/1set/todotxt"
+	"t/utils"
 )
 
 // WorkPackage represents the structure of a work package from OpenProject's API
@@ -29,8 +32,8 @@ type WorkPackage struct {
     ScheduleManually   bool `json:"scheduleManually"`
     StartDate          string `json:"startDate"`
     DueDate            string `json:"dueDate"`
-    EstimatedTime      *int   `json:"estimatedTime,omitempty"`
-    DerivedEstimatedTime *int `json:"derivedEstimatedTime,omitempty"`
+    EstimatedTime      *string `json:"estimatedTime,omitempty"` // ISO 8601 duration, e.g. PT2H30M
+    DerivedEstimatedTime *string `json:"derivedEstimatedTime,omitempty"`
     Duration           string `json:"duration"`
     IgnoreNonWorkingDays bool `json:"ignoreNonWorkingDays"`
     PercentageDone     int    `json:"percentageDone"`
@@ -156,6 +159,30 @@ func EnsureSpaceAtEnd(prefix string) string {
 	return prefix + " "
 }
 
+// ParseDuration parses an ISO 8601 duration as used by the OpenProject API, e.g. PT2H30M
+func ParseDuration(s string) (time.Duration, error) {
+	m := isoDurationRx.FindStringSubmatch(s)
+	if m == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return 0, fmt.Errorf("invalid ISO 8601 duration: %q", s)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+1] == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(m[i+1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ISO 8601 duration: %q", s)
+		}
+		d += time.Duration(value * float64(unit))
+	}
+	return d, nil
+}
+
+var isoDurationRx = regexp.MustCompile(`^P(?:([\d.]+)W)?(?:([\d.]+)D)?(?:T(?:([\d.]+)H)?(?:([\d.]+)M)?(?:([\d.]+)S)?)?$`)
+
 func CreateTaskList(workPackages []WorkPackage, prefix string, opUrl string) todo.TaskList {
 	tl := todo.NewTaskList()
 
@@ -193,6 +220,14 @@ func CreateTaskList(workPackages []WorkPackage, prefix string, opUrl string) tod
 			}
 		}
 
+		// Set the estimate if available
+		if wp.EstimatedTime != nil {
+			estimate, err := ParseDuration(*wp.EstimatedTime)
+			if err == nil && estimate > 0 {
+				to.AdditionalTags["est"] = utils.FormatEstimate(estimate)
+			}
+		}
+
 		// Map project
 		//to.Projects = []string{wp.Links.Project.Title}
 
@@ -0,0 +1,124 @@
+package remote
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"t/sync/state"
+	"t/todo"
+)
+
+// ErrChanged is returned by File.Write if the remote file changed since the given version was read
+var ErrChanged = errors.New("remote file was changed concurrently")
+
+// File is a todo file at a remote location
+type File interface {
+	// Read returns the content of the file and an identifier of its version, e.g. an ETag.
+	// It returns exists = false if there is no such file.
+	Read() (content []byte, version string, exists bool, err error)
+	// Write replaces the file if it still has the given version, or creates it if version is empty
+	// and the file does not exist. It returns ErrChanged if that condition does not hold, and the
+	// version of the written file otherwise, or an empty version if it cannot be determined.
+	Write(content []byte, version string) (string, error)
+}
+
+// maxAttempts limits how often a sync is retried when the remote file changes concurrently
+const maxAttempts = 3
+
+// SyncResult contains information about the sync of a file
+type SyncResult struct {
+	Uploaded   bool // The local file was written to the remote location
+	Downloaded bool // The local file was replaced by the remote file
+	Merged     bool // Local and remote changes were merged
+	Conflicts  []todo.MergeConflict
+}
+
+// Sync syncs the local todo file at path with a remote file. Writes are conditional on the
+// remote version that was read, so a concurrent change leads to a task-by-task merge
+// instead of being overwritten. The last synced version is kept in st as the merge base.
+func Sync(file File, path string, st *state.State) (*SyncResult, error) {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		result, err := syncOnce(file, path, st)
+		if errors.Is(err, ErrChanged) {
+			continue
+		}
+		return result, err
+	}
+	return nil, fmt.Errorf("giving up after %d attempts: %v", maxAttempts, ErrChanged)
+}
+
+func syncOnce(file File, path string, st *state.State) (*SyncResult, error) {
+	result := &SyncResult{}
+
+	local, err := os.ReadFile(path)
+	localExists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, &todo.FileError{Op: "read", Path: path, Err: err}
+	}
+
+	remote, version, remoteExists, err := file.Read()
+	if err != nil {
+		return nil, err
+	}
+	// A new version with the same content counts as unchanged, e.g. a touched file
+	remoteUnchanged := remoteExists && !st.IsNew() && (version == st.ETag || st.Unchanged(remote))
+
+	switch {
+	case !remoteExists && !localExists:
+		return result, nil
+
+	case localExists && (!remoteExists || remoteUnchanged):
+		// Only the local file changed
+		if remoteExists && st.Unchanged(local) {
+			return result, st.Save(local, version)
+		}
+		newVersion, err := file.Write(local, version)
+		if err != nil {
+			return nil, err
+		}
+		result.Uploaded = true
+		return result, st.Save(local, newVersion)
+
+	case !localExists || st.Unchanged(local) || bytes.Equal(local, remote):
+		// Only the remote file changed
+		if !localExists || !bytes.Equal(local, remote) {
+			if err := writeFile(path, remote); err != nil {
+				return nil, err
+			}
+			result.Downloaded = true
+		}
+		return result, st.Save(remote, version)
+	}
+
+	// Both files changed
+	base, err := st.Base()
+	if err != nil {
+		return nil, err
+	}
+	merged, mergeResult, err := todo.MergeContents(base, local, remote)
+	if err != nil {
+		return nil, err
+	}
+	result.Merged = true
+	result.Conflicts = mergeResult.Conflicts
+
+	if !bytes.Equal(merged, remote) {
+		if version, err = file.Write(merged, version); err != nil {
+			return nil, err
+		}
+		result.Uploaded = true
+	}
+	if err := writeFile(path, merged); err != nil {
+		return nil, err
+	}
+	return result, st.Save(merged, version)
+}
+
+func writeFile(path string, content []byte) error {
+	if err := os.WriteFile(path, content, 0640); err != nil {
+		return &todo.FileError{Op: "write", Path: path, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package sftp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/pkg/sftp"
+
+	"t/sync/remote"
+)
+
+// File is a todo file on an SFTP server
+type File struct {
+	Client *sftp.Client
+	Path   string
+}
+
+// Read downloads the file. Its version consists of its modification time and a hash of its content.
+func (f *File) Read() ([]byte, string, bool, error) {
+	info, err := f.Client.Stat(f.Path)
+	if os.IsNotExist(err) {
+		return nil, "", false, nil
+	}
+	if err != nil {
+		return nil, "", false, fmt.Errorf("error getting remote file info: %v", err)
+	}
+
+	file, err := f.Client.Open(f.Path)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("error opening remote file: %v", err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("error reading remote file: %v", err)
+	}
+	return content, version(info.ModTime(), content), true, nil
+}
+
+// Write uploads the file to a temporary file next to it and renames it over the file, so the
+// file is never seen half written. SFTP has no conditional writes, so the version is checked
+// right before the rename.
+func (f *File) Write(content []byte, expected string) (string, error) {
+	if err := f.Client.MkdirAll(path.Dir(f.Path)); err != nil {
+		return "", fmt.Errorf("error creating remote directory: %v", err)
+	}
+
+	tmpPath := f.Path + ".t-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := f.upload(tmpPath, content); err != nil {
+		f.Client.Remove(tmpPath)
+		return "", err
+	}
+
+	_, current, exists, err := f.Read()
+	if err != nil || current != expected {
+		f.Client.Remove(tmpPath)
+		if err != nil {
+			return "", err
+		}
+		return "", remote.ErrChanged
+	}
+
+	if err := f.rename(tmpPath, exists); err != nil {
+		return "", fmt.Errorf("error renaming remote file, the new content was left in %s: %v", tmpPath, err)
+	}
+
+	info, err := f.Client.Stat(f.Path)
+	if err != nil {
+		return "", fmt.Errorf("error getting remote file info: %v", err)
+	}
+	return version(info.ModTime(), content), nil
+}
+
+// rename moves the temporary file to the path of the file. Servers without the posix-rename
+// extension cannot rename over an existing file, so the old file is moved aside first and
+// restored if the rename fails.
+func (f *File) rename(tmpPath string, exists bool) error {
+	if _, ok := f.Client.HasExtension("posix-rename@openssh.com"); ok {
+		return f.Client.PosixRename(tmpPath, f.Path)
+	}
+	if !exists {
+		return f.Client.Rename(tmpPath, f.Path)
+	}
+
+	oldPath := tmpPath + ".old"
+	if err := f.Client.Rename(f.Path, oldPath); err != nil {
+		return err
+	}
+	if err := f.Client.Rename(tmpPath, f.Path); err != nil {
+		f.Client.Rename(oldPath, f.Path)
+		return err
+	}
+	f.Client.Remove(oldPath)
+	return nil
+}
+
+func (f *File) upload(tmpPath string, content []byte) error {
+	file, err := f.Client.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("error creating remote file: %v", err)
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing remote file: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error writing remote file: %v", err)
+	}
+	return nil
+}
+
+// version identifies a version of the remote file by its modification time and content
+func version(modTime time.Time, content []byte) string {
+	sum := sha256.Sum256(content)
+	return strconv.FormatInt(modTime.Unix(), 10) + "-" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,198 @@
+package sftp
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/adrg/xdg"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"t/sync/remote"
+	"t/sync/state"
+)
+
+// startServer starts an in-process SSH server with an SFTP subsystem serving root.
+// Only the given client key is accepted. The server has an ECDSA host key, which clients
+// prefer by default, and the returned ed25519 host key.
+func startServer(t *testing.T, root string, clientKey ssh.PublicKey) (addr string, hostKey ssh.PublicKey) {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, ssh.ErrNoAuth
+		},
+	}
+	config.AddHostKey(signer)
+	ecdsaPrivate, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecdsaSigner, err := ssh.NewSignerFromKey(ecdsaPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.AddHostKey(ecdsaSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveConn(conn, config, root)
+		}
+	}()
+	return listener.Addr().String(), signer.PublicKey()
+}
+
+func serveConn(conn net.Conn, config *ssh.ServerConfig, root string) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range channelRequests {
+				isSFTP := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+				req.Reply(isSFTP, nil)
+				if isSFTP {
+					server, err := sftp.NewServer(channel, sftp.WithServerWorkingDirectory(root))
+					if err == nil {
+						server.Serve()
+					}
+					channel.Close()
+				}
+			}
+		}()
+	}
+}
+
+func TestSync(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("SSH_AUTH_SOCK", "")
+	xdg.Reload()
+
+	dir := t.TempDir()
+	remoteDir := filepath.Join(dir, "remote")
+	if err := os.Mkdir(remoteDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Client key and known hosts
+	_, clientPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	clientSigner, _ := ssh.NewSignerFromKey(clientPrivate)
+	block, err := ssh.MarshalPrivateKey(clientPrivate, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	identityFile := filepath.Join(dir, "id_ed25519")
+	if err := os.WriteFile(identityFile, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	addr, hostKey := startServer(t, remoteDir, clientSigner.PublicKey())
+	host, portStr, _ := net.SplitHostPort(addr)
+	port, _ := strconv.Atoi(portStr)
+
+	knownHostsFile := filepath.Join(dir, "known_hosts")
+	target, err := ParseTarget("me@" + host + ":notes/todo.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DialConfig{Port: port, IdentityFiles: []string{identityFile}, KnownHostsFile: knownHostsFile}
+
+	// Unknown host keys are rejected
+	os.WriteFile(knownHostsFile, nil, 0600)
+	if _, err := Dial(target, config); err == nil {
+		t.Fatal("Dial() with unknown host key succeeded")
+	}
+
+	// Only the ed25519 host key is known, the server has to present it instead of its ECDSA key
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, hostKey)
+	if err := os.WriteFile(knownHostsFile, []byte(line+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := Dial(target, config)
+	if err != nil {
+		t.Fatalf("Dial() failed: %v", err)
+	}
+	defer conn.Close()
+
+	file := &File{Client: conn.Client, Path: target.Path}
+	syncFile := func(name string) *remote.SyncResult {
+		t.Helper()
+		st, err := state.Load("sftp", filepath.Join(dir, name), target.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := remote.Sync(file, filepath.Join(dir, name), st)
+		if err != nil {
+			t.Fatalf("Sync(%s) failed: %v", name, err)
+		}
+		return result
+	}
+
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\n"), 0640)
+	if result := syncFile("a.txt"); !result.Uploaded {
+		t.Errorf("first Sync() = %+v, want upload", result)
+	}
+	if result := syncFile("b.txt"); !result.Downloaded {
+		t.Errorf("Sync() of new location = %+v, want download", result)
+	}
+
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01\n"), 0640)
+	syncFile("b.txt")
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\nBuy milk\n"), 0640)
+	if result := syncFile("a.txt"); !result.Merged || !result.Uploaded {
+		t.Errorf("Sync() after diverging = %+v, want merge and upload", result)
+	}
+
+	want := "Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01\nBuy milk\n"
+	got, err := os.ReadFile(filepath.Join(remoteDir, "notes", "todo.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("remote file = %q, want %q", got, want)
+	}
+
+	// Writing a stale version fails instead of overwriting the remote file
+	if _, err := file.Write([]byte("stale\n"), "0-stale"); err != remote.ErrChanged {
+		t.Errorf("Write() with stale version = %v, want remote.ErrChanged", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(remoteDir, "notes")); len(entries) != 1 {
+		t.Errorf("remote directory has %d entries, want only todo.txt", len(entries))
+	}
+}
@@ -0,0 +1,172 @@
+package sftp
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Target is a file on a remote host in scp notation: [user@]host:path
+type Target struct {
+	User string
+	Host string
+	Path string // Relative paths are relative to the home directory on the host
+}
+
+// ParseTarget parses a target in scp notation
+func ParseTarget(s string) (Target, error) {
+	var t Target
+	hostPath := s
+	if i := strings.LastIndex(s, "@"); i >= 0 {
+		t.User, hostPath = s[:i], s[i+1:]
+	}
+	i := strings.Index(hostPath, ":")
+	if i <= 0 || i == len(hostPath)-1 {
+		return t, fmt.Errorf("invalid SFTP target %q, expected [user@]host:path", s)
+	}
+	t.Host, t.Path = hostPath[:i], hostPath[i+1:]
+
+	if t.User == "" {
+		current, err := user.Current()
+		if err != nil {
+			return t, fmt.Errorf("cannot determine user name: %v", err)
+		}
+		t.User = current.Username
+	}
+	return t, nil
+}
+
+func (t Target) String() string {
+	return t.User + "@" + t.Host + ":" + t.Path
+}
+
+// DialConfig holds the options for connecting to an SFTP server
+type DialConfig struct {
+	Port           int
+	IdentityFiles  []string // Private key files, files that do not exist or need a passphrase are skipped
+	KnownHostsFile string   // Host keys are verified against this file
+}
+
+// Connection is an SSH connection with an SFTP session
+type Connection struct {
+	*sftp.Client
+	ssh *ssh.Client
+}
+
+// Close closes the SFTP session and the SSH connection
+func (c *Connection) Close() error {
+	c.Client.Close()
+	return c.ssh.Close()
+}
+
+// Dial connects to the host of the target, authenticating with the keys of the SSH agent and
+// the identity files
+func Dial(target Target, config DialConfig) (*Connection, error) {
+	hostKeyCallback, err := knownhosts.New(config.KnownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading known hosts: %v", err)
+	}
+
+	var auth []ssh.AuthMethod
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			defer conn.Close()
+			auth = append(auth, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+	if signers := loadIdentityFiles(config.IdentityFiles); len(signers) > 0 {
+		auth = append(auth, ssh.PublicKeys(signers...))
+	}
+	if len(auth) == 0 {
+		return nil, fmt.Errorf("no SSH agent and no usable identity file found")
+	}
+
+	addr := net.JoinHostPort(target.Host, fmt.Sprint(config.Port))
+	sshClient, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:              target.User,
+		Auth:              auth,
+		HostKeyCallback:   hostKeyCallback,
+		HostKeyAlgorithms: hostKeyAlgorithms(hostKeyCallback, addr),
+	})
+	if err != nil {
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) && len(keyErr.Want) == 0 {
+			return nil, fmt.Errorf("host key of %s is unknown, connect once with ssh to verify and add it to %s", target.Host, config.KnownHostsFile)
+		}
+		return nil, fmt.Errorf("error connecting to %s: %v", addr, err)
+	}
+
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		return nil, fmt.Errorf("error starting SFTP session: %v", err)
+	}
+	return &Connection{Client: client, ssh: sshClient}, nil
+}
+
+// hostKeyAlgorithms returns the algorithms of the host keys known for addr, so that a server
+// with several host keys presents one of them instead of the one it prefers. It returns nil
+// for unknown hosts, leaving the choice to the server.
+func hostKeyAlgorithms(hostKeyCallback ssh.HostKeyCallback, addr string) []string {
+	// Checking a key that cannot be known reports the known keys
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil
+	}
+	signer, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		return nil
+	}
+	var keyErr *knownhosts.KeyError
+	if err := hostKeyCallback(addr, &net.TCPAddr{}, signer.PublicKey()); !errors.As(err, &keyErr) {
+		return nil
+	}
+
+	var algorithms []string
+	seen := make(map[string]bool)
+	for _, known := range keyErr.Want {
+		keyType := known.Key.Type()
+		for _, algorithm := range keyAlgorithms(keyType) {
+			if !seen[algorithm] {
+				seen[algorithm] = true
+				algorithms = append(algorithms, algorithm)
+			}
+		}
+	}
+	return algorithms
+}
+
+// keyAlgorithms returns the signature algorithms usable with a host key type
+func keyAlgorithms(keyType string) []string {
+	if keyType == ssh.KeyAlgoRSA {
+		return []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+	}
+	return []string{keyType}
+}
+
+// loadIdentityFiles loads the private keys that can be used without a passphrase
+func loadIdentityFiles(paths []string) []ssh.Signer {
+	var signers []ssh.Signer
+	for _, path := range paths {
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(bs)
+		if err != nil {
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	return signers
+}
@@ -0,0 +1,103 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/adrg/xdg"
+)
+
+// State records the last successful sync of a local file with a remote location. Besides the metadata,
+// a copy of the synced content is kept as the common ancestor for merging diverged versions.
+type State struct {
+	Local    string    `json:"local"`          // Absolute path of the local file
+	Location string    `json:"location"`       // Remote location, e.g. a URL or path
+	ETag     string    `json:"etag,omitempty"` // Remote version identifier, if the backend has one
+	Hash     string    `json:"hash"`           // Hash of the synced content
+	SyncedAt time.Time `json:"synced_at"`
+
+	path string // Path of the state file
+}
+
+// Load loads the state of the last sync of the local file with a location. If there was none, an empty
+// state is returned. State files are kept in the XDG state directory, separated by backend.
+func Load(backend, local, location string) (*State, error) {
+	local, err := filepath.Abs(local)
+	if err != nil {
+		return nil, fmt.Errorf("error locating sync state: %v", err)
+	}
+	sum := sha256.Sum256([]byte(local + "\n" + location))
+	path, err := xdg.StateFile(filepath.Join("t", "sync", backend, hex.EncodeToString(sum[:8])+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("error locating sync state: %v", err)
+	}
+
+	s := &State{Local: local, Location: location, path: path}
+	bs, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading sync state: %v", err)
+	}
+	if err := json.Unmarshal(bs, s); err != nil {
+		return nil, fmt.Errorf("error unmarshaling sync state %s: %v", path, err)
+	}
+	return s, nil
+}
+
+// IsNew reports whether the location was never synced before
+func (s *State) IsNew() bool {
+	return s.Hash == ""
+}
+
+// Unchanged reports whether content equals the content of the last sync
+func (s *State) Unchanged(content []byte) bool {
+	return !s.IsNew() && s.Hash == Hash(content)
+}
+
+// Base returns the content of the last sync, or nil if there was none
+func (s *State) Base() ([]byte, error) {
+	if s.IsNew() {
+		return nil, nil
+	}
+	bs, err := os.ReadFile(s.basePath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return bs, err
+}
+
+// Save records a successful sync of content with the given remote version identifier
+func (s *State) Save(content []byte, etag string) error {
+	s.ETag = etag
+	s.Hash = Hash(content)
+	s.SyncedAt = time.Now()
+
+	bs, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling sync state: %v", err)
+	}
+	if err := os.WriteFile(s.basePath(), content, 0600); err != nil {
+		return fmt.Errorf("error writing sync state: %v", err)
+	}
+	if err := os.WriteFile(s.path, bs, 0600); err != nil {
+		return fmt.Errorf("error writing sync state: %v", err)
+	}
+	return nil
+}
+
+func (s *State) basePath() string {
+	return s.path[:len(s.path)-len(filepath.Ext(s.path))] + ".base"
+}
+
+// Hash returns the hash of content as recorded in the state
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,160 @@
+package webdav
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"t/sync/remote"
+)
+
+// Client accesses a single file on a WebDAV server, e.g. a Nextcloud file at
+// https://cloud.example.org/remote.php/dav/files/<user>/todo.txt
+type Client struct {
+	URL      string
+	Username string
+	Password string
+	HTTP     *http.Client
+}
+
+// NewClient creates a client for the file at fileURL
+func NewClient(fileURL, username, password string) *Client {
+	return &Client{URL: fileURL, Username: username, Password: password, HTTP: &http.Client{}}
+}
+
+// Read downloads the file and returns it along with its ETag. It returns exists = false if there is no such file.
+func (c *Client) Read() (content []byte, etag string, exists bool, err error) {
+	resp, err := c.do("GET", c.URL, nil, nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", false, fmt.Errorf("HTTP error! status: %d", resp.StatusCode)
+	}
+
+	content, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("error reading response body: %v", err)
+	}
+	etag = resp.Header.Get("ETag")
+	if etag == "" {
+		return nil, "", false, fmt.Errorf("WebDAV server sent no ETag, conditional uploads are not possible")
+	}
+	return content, etag, true, nil
+}
+
+// Write uploads the file if the remote version still has the given ETag, or if it does not exist
+// yet when etag is empty. It returns remote.ErrChanged if that condition does not hold,
+// and the ETag of the uploaded version otherwise, which is empty if the server did not send it
+// and the file changed again before it could be read.
+func (c *Client) Write(content []byte, etag string) (string, error) {
+	header := http.Header{}
+	if etag == "" {
+		header.Set("If-None-Match", "*")
+	} else {
+		header.Set("If-Match", etag)
+	}
+
+	resp, err := c.do("PUT", c.URL, content, header)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	// The parent collection is missing, create it and try again. RFC 4918 asks for 409,
+	// some servers answer 404.
+	missingParent := resp.StatusCode == http.StatusConflict || resp.StatusCode == http.StatusNotFound
+	if missingParent && etag == "" {
+		if err := c.makeCollections(); err != nil {
+			return "", err
+		}
+		if resp, err = c.do("PUT", c.URL, content, header); err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+	case http.StatusPreconditionFailed:
+		return "", remote.ErrChanged
+	default:
+		return "", fmt.Errorf("HTTP error! status: %d", resp.StatusCode)
+	}
+
+	// Nextcloud sends the ETag of the new version, other servers may not. The ETag of a read
+	// is only ours if nobody wrote in between, otherwise the version stays unknown.
+	if newETag := resp.Header.Get("ETag"); newETag != "" {
+		return newETag, nil
+	}
+	written, newETag, _, err := c.Read()
+	if err != nil {
+		return "", err
+	}
+	if !bytes.Equal(written, content) {
+		return "", nil
+	}
+	return newETag, nil
+}
+
+// makeCollections creates the parent collection of the file and any missing collections above it
+func (c *Client) makeCollections() error {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("invalid WebDAV URL: %v", err)
+	}
+	return c.makeCollection(u, path.Dir(strings.TrimSuffix(u.Path, "/")))
+}
+
+func (c *Client) makeCollection(u *url.URL, dir string) error {
+	if dir == "/" || dir == "." {
+		return fmt.Errorf("error creating collections for %s", c.URL)
+	}
+	collection := *u
+	collection.Path = dir + "/"
+
+	resp, err := c.do("MKCOL", collection.String(), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusMethodNotAllowed: // 405 means the collection exists
+		return nil
+	case http.StatusConflict: // the parent collection is missing as well
+		if err := c.makeCollection(u, path.Dir(dir)); err != nil {
+			return err
+		}
+		return c.makeCollection(u, dir)
+	}
+	return fmt.Errorf("error creating collection %s: HTTP status %d", dir, resp.StatusCode)
+}
+
+func (c *Client) do(method, url string, body []byte, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if c.Username != "" || c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %v", err)
+	}
+	return resp, nil
+}
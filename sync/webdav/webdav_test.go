@@ -0,0 +1,175 @@
+package webdav
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/adrg/xdg"
+	"golang.org/x/net/webdav"
+
+	"t/sync/remote"
+	"t/sync/state"
+)
+
+// conditionalHandler adds If-Match and If-None-Match support for PUT requests to a webdav.Handler,
+// which only evaluates lock conditions. Servers like Nextcloud support both.
+type conditionalHandler struct {
+	mu      sync.Mutex
+	handler *webdav.Handler
+}
+
+func newServer(fs webdav.FileSystem) *httptest.Server {
+	return httptest.NewServer(&conditionalHandler{handler: &webdav.Handler{FileSystem: fs, LockSystem: webdav.NewMemLS()}})
+}
+
+func (h *conditionalHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if r.Method == "PUT" {
+		head := httptest.NewRecorder()
+		h.handler.ServeHTTP(head, httptest.NewRequest("HEAD", r.URL.Path, nil))
+		exists := head.Code == http.StatusOK
+		ifMatch, ifNoneMatch := r.Header.Get("If-Match"), r.Header.Get("If-None-Match")
+		if (ifMatch != "" && (!exists || ifMatch != head.Header().Get("ETag"))) || (ifNoneMatch == "*" && exists) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+	}
+	h.handler.ServeHTTP(w, r)
+}
+
+func TestSync(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	xdg.Reload()
+
+	server := newServer(webdav.NewMemFS())
+	defer server.Close()
+
+	fileURL := server.URL + "/remote.php/dav/files/me/todo.txt"
+	dir := t.TempDir()
+
+	syncFile := func(name string) *remote.SyncResult {
+		t.Helper()
+		st, err := state.Load("webdav", filepath.Join(dir, name), fileURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := remote.Sync(NewClient(fileURL, "me", "secret"), filepath.Join(dir, name), st)
+		if err != nil {
+			t.Fatalf("Sync(%s) failed: %v", name, err)
+		}
+		return result
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(name string) string {
+		bs, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(bs)
+	}
+
+	// The first upload creates the missing collections
+	write("a.txt", "Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\nBuy milk id:tI4JeTHbMqlSrWPjtn3Zzf\n")
+	if result := syncFile("a.txt"); !result.Uploaded {
+		t.Errorf("first Sync() = %+v, want upload", result)
+	}
+
+	if result := syncFile("b.txt"); !result.Downloaded {
+		t.Errorf("Sync() of new location = %+v, want download", result)
+	}
+	if read("b.txt") != read("a.txt") {
+		t.Errorf("downloaded file = %q, want %q", read("b.txt"), read("a.txt"))
+	}
+
+	// Both sides change different tasks
+	write("b.txt", "Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01\nBuy milk id:tI4JeTHbMqlSrWPjtn3Zzf\n")
+	syncFile("b.txt")
+	write("a.txt", "Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t\nx Buy milk id:tI4JeTHbMqlSrWPjtn3Zzf\n")
+	if result := syncFile("a.txt"); !result.Merged || !result.Uploaded || len(result.Conflicts) != 0 {
+		t.Errorf("Sync() after concurrent changes = %+v, want clean merge and upload", result)
+	}
+
+	want := "Write chapter id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01\nx Buy milk id:tI4JeTHbMqlSrWPjtn3Zzf\n"
+	if got := read("a.txt"); got != want {
+		t.Errorf("merged file = %q, want %q", got, want)
+	}
+	content, _, _, err := NewClient(fileURL, "me", "secret").Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != want {
+		t.Errorf("remote file = %q, want %q", content, want)
+	}
+
+	if result := syncFile("a.txt"); result.Uploaded || result.Downloaded || result.Merged {
+		t.Errorf("Sync() without changes = %+v, want nothing to do", result)
+	}
+}
+
+func TestWriteChanged(t *testing.T) {
+	server := newServer(webdav.NewMemFS())
+	defer server.Close()
+
+	client := NewClient(server.URL+"/todo.txt", "", "")
+	etag, err := client.Write([]byte("first\n"), "")
+	if err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	if _, err := client.Write([]byte("second\n"), etag); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+	if _, err := client.Write([]byte("third\n"), etag); err != remote.ErrChanged {
+		t.Errorf("Write() with stale ETag = %v, want remote.ErrChanged", err)
+	}
+	if _, err := client.Write([]byte("fourth\n"), ""); err != remote.ErrChanged {
+		t.Errorf("Write() creating an existing file = %v, want remote.ErrChanged", err)
+	}
+}
+
+// noETagHandler answers PUT requests without ETag and lets another client write right after
+type noETagHandler struct {
+	http.Handler
+	intervene []byte
+}
+
+func (h *noETagHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "PUT" {
+		h.Handler.ServeHTTP(w, r)
+		return
+	}
+	resp := httptest.NewRecorder()
+	h.Handler.ServeHTTP(resp, r)
+	if h.intervene != nil {
+		h.Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PUT", r.URL.Path, bytes.NewReader(h.intervene)))
+	}
+	w.WriteHeader(resp.Code)
+}
+
+func TestWriteWithoutETag(t *testing.T) {
+	handler := &noETagHandler{Handler: &conditionalHandler{handler: &webdav.Handler{FileSystem: webdav.NewMemFS(), LockSystem: webdav.NewMemLS()}}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	client := NewClient(server.URL+"/todo.txt", "", "")
+	etag, err := client.Write([]byte("first\n"), "")
+	if err != nil || etag == "" {
+		t.Fatalf("Write() = %q, %v, want the ETag read back", etag, err)
+	}
+
+	// The ETag read back belongs to another version, it must not be taken for ours
+	handler.intervene = []byte("other\n")
+	if etag, err = client.Write([]byte("second\n"), etag); err != nil || etag != "" {
+		t.Errorf("Write() with intervening write = %q, %v, want an empty version", etag, err)
+	}
+}
@@ -0,0 +1,173 @@
+package timeclock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Exporter renders entries in the format of another tool. Entries that are still checked in are skipped.
+type Exporter func(w io.Writer, entries []Entry) error
+
+// Exporters are the supported export formats by name
+var Exporters = map[string]Exporter{
+	"timewarrior":     ExportTimewarrior,
+	"hledger-timedot": ExportTimedot,
+	"ical":            ExportICal,
+}
+
+// ExportTimewarrior writes entries as JSON for timew import. The account, projects and contexts
+// become tags, the description the annotation.
+func ExportTimewarrior(w io.Writer, entries []Entry) error {
+	type interval struct {
+		ID         int      `json:"id"`
+		Start      string   `json:"start"`
+		End        string   `json:"end"`
+		Tags       []string `json:"tags,omitempty"`
+		Annotation string   `json:"annotation,omitempty"`
+	}
+	intervals := []interval{}
+	for _, e := range closed(entries) {
+		intervals = append(intervals, interval{
+			ID:         len(intervals) + 1,
+			Start:      e.Start.UTC().Format(timewarriorLayout),
+			End:        e.End.UTC().Format(timewarriorLayout),
+			Tags:       tags(e),
+			Annotation: e.Description,
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(intervals)
+}
+
+// ExportTimedot writes entries as hledger timedot journal: the hours per day and account, with
+// the contexts as tags
+func ExportTimedot(w io.Writer, entries []Entry) error {
+	type key struct{ account, tags string }
+	var days []string
+	var keys = make(map[string][]key)
+	hours := make(map[string]map[key]float64)
+	for _, e := range closed(entries) {
+		day := e.Start.Local().Format("2006-01-02")
+		if hours[day] == nil {
+			days = append(days, day)
+			hours[day] = make(map[key]float64)
+		}
+		account := e.Account
+		if account == "" {
+			account = DefaultAccount
+		}
+		var contextTags []string
+		for _, context := range e.Contexts() {
+			contextTags = append(contextTags, context+":")
+		}
+		k := key{account, strings.Join(contextTags, ", ")}
+		if _, ok := hours[day][k]; !ok {
+			keys[day] = append(keys[day], k)
+		}
+		hours[day][k] += e.Duration().Hours()
+	}
+
+	for i, day := range days {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, day)
+		for _, k := range keys[day] {
+			line := fmt.Sprintf("%s  %.2f", k.account, hours[day][k])
+			if k.tags != "" {
+				line += "  ; " + k.tags
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// icalLayout is the UTC date-time layout of iCalendar
+const icalLayout = "20060102T150405Z"
+
+// ExportICal writes entries as iCalendar events. The account, projects and contexts become categories.
+func ExportICal(w io.Writer, entries []Entry) error {
+	lines := []string{"BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:-//t//time export//EN"}
+	now := time.Now().UTC().Format(icalLayout)
+	for _, e := range closed(entries) {
+		sum := sha256.Sum256([]byte(e.String()))
+		summary := e.Description
+		if summary == "" {
+			summary = e.Account
+		}
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+hex.EncodeToString(sum[:16])+"@t",
+			"DTSTAMP:"+now,
+			"DTSTART:"+e.Start.UTC().Format(icalLayout),
+			"DTEND:"+e.End.UTC().Format(icalLayout),
+			"SUMMARY:"+icalEscape(summary),
+		)
+		if categories := tags(e); len(categories) > 0 {
+			for i := range categories {
+				categories[i] = icalEscape(categories[i])
+			}
+			lines = append(lines, "CATEGORIES:"+strings.Join(categories, ","))
+		}
+		lines = append(lines, "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		if _, err := io.WriteString(w, foldICalLine(line)+"\r\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func icalEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// foldICalLine breaks lines longer than 75 bytes as required by RFC 5545
+func foldICalLine(line string) string {
+	var sb strings.Builder
+	for len(line) > 75 {
+		cut := 75
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut-- // Do not split UTF-8 sequences
+		}
+		sb.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+	sb.WriteString(line)
+	return sb.String()
+}
+
+// tags returns the account, projects and contexts of an entry without duplicates
+func tags(e Entry) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, tag := range append(append([]string{e.Account}, e.Projects()...), e.Contexts()...) {
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func closed(entries []Entry) []Entry {
+	var result []Entry
+	for _, e := range entries {
+		if !e.End.IsZero() {
+			result = append(result, e)
+		}
+	}
+	return result
+}
@@ -0,0 +1,161 @@
+package timeclock
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Importer converts the export of another time tracker into entries. Projects become the
+// account and a +project in the description, tags become @contexts.
+type Importer func(r io.Reader) ([]Entry, error)
+
+// Importers are the supported export formats by name
+var Importers = map[string]Importer{
+	"watson":      ImportWatson,
+	"timewarrior": ImportTimewarrior,
+	"toggl-csv":   ImportTogglCSV,
+}
+
+// ImportWatson reads the JSON output of watson log --json
+func ImportWatson(r io.Reader) ([]Entry, error) {
+	var frames []struct {
+		Project string    `json:"project"`
+		Start   time.Time `json:"start"`
+		Stop    time.Time `json:"stop"`
+		Tags    []string  `json:"tags"`
+		Note    string    `json:"note"`
+	}
+	if err := json.NewDecoder(r).Decode(&frames); err != nil {
+		return nil, fmt.Errorf("invalid Watson export: %v", err)
+	}
+
+	var entries []Entry
+	for _, f := range frames {
+		entries = append(entries, newEntry(f.Start, f.Stop, f.Project, f.Note, f.Tags))
+	}
+	return sortEntries(entries), nil
+}
+
+// timewarriorLayout is the time layout of timew export
+const timewarriorLayout = "20060102T150405Z"
+
+// ImportTimewarrior reads the JSON output of timew export. Timewarrior only has tags: tags
+// containing spaces are taken as description, unless there is an annotation, the first other
+// tag as project and the rest as contexts. Tags starting with + or @ keep their meaning.
+func ImportTimewarrior(r io.Reader) ([]Entry, error) {
+	var intervals []struct {
+		Start      string   `json:"start"`
+		End        string   `json:"end"`
+		Tags       []string `json:"tags"`
+		Annotation string   `json:"annotation"`
+	}
+	if err := json.NewDecoder(r).Decode(&intervals); err != nil {
+		return nil, fmt.Errorf("invalid Timewarrior export: %v", err)
+	}
+
+	var entries []Entry
+	for _, in := range intervals {
+		if in.End == "" {
+			continue // Still running
+		}
+		start, err := time.Parse(timewarriorLayout, in.Start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Timewarrior start %q: %v", in.Start, err)
+		}
+		end, err := time.Parse(timewarriorLayout, in.End)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Timewarrior end %q: %v", in.End, err)
+		}
+
+		project, description := "", in.Annotation
+		var tags []string
+		for _, tag := range in.Tags {
+			switch {
+			case strings.ContainsAny(tag, " \t"):
+				if description == "" {
+					description = tag
+				}
+			case strings.HasPrefix(tag, "+") && project == "":
+				project = tag[1:]
+			case strings.HasPrefix(tag, "@"):
+				tags = append(tags, tag[1:])
+			case !strings.HasPrefix(tag, "+") && project == "":
+				project = tag
+			default:
+				tags = append(tags, strings.TrimPrefix(tag, "+"))
+			}
+		}
+		entries = append(entries, newEntry(start, end, project, description, tags))
+	}
+	return sortEntries(entries), nil
+}
+
+// ImportTogglCSV reads the detailed CSV report of Toggl Track. Times are taken as local time.
+func ImportTogglCSV(r io.Reader) ([]Entry, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid Toggl CSV export: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	for _, name := range []string{"Project", "Description", "Start date", "Start time", "End date", "End time"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("invalid Toggl CSV export: missing column %q", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var entries []Entry
+	for n, record := range records[1:] {
+		start, err := time.ParseInLocation("2006-01-02 15:04:05", field(record, "Start date")+" "+field(record, "Start time"), time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start in row %d: %v", n+2, err)
+		}
+		end, err := time.ParseInLocation("2006-01-02 15:04:05", field(record, "End date")+" "+field(record, "End time"), time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end in row %d: %v", n+2, err)
+		}
+		var tags []string
+		for _, tag := range strings.Split(field(record, "Tags"), ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		entries = append(entries, newEntry(start, end, field(record, "Project"), field(record, "Description"), tags))
+	}
+	return sortEntries(entries), nil
+}
+
+// newEntry creates an entry with the project as account and +project and @contexts in the description
+func newEntry(start, end time.Time, project, description string, contexts []string) Entry {
+	project = strings.Join(strings.Fields(project), "-")
+	words := strings.Fields(description)
+	if project != "" {
+		words = append(words, "+"+project)
+	}
+	for _, context := range contexts {
+		words = append(words, "@"+strings.Join(strings.Fields(context), "-"))
+	}
+	return Entry{Start: start, End: end, Account: project, Description: strings.Join(words, " ")}
+}
+
+func sortEntries(entries []Entry) []Entry {
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Start.Before(entries[j].Start) })
+	return entries
+}
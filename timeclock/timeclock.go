@@ -0,0 +1,210 @@
+// Package timeclock reads and writes time entries in the timeclock format, see
+// https://hledger.org/1.40/hledger.html#timeclock-format
+package timeclock
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Layout is the date and time layout of check-in and check-out lines
+const Layout = "2006/01/02 15:04:05"
+
+// DefaultAccount is used for entries that have no account, e.g. imported ones without project
+const DefaultAccount = "unassigned"
+
+// Entry is a span of time spent on an account, from a check-in to a check-out line
+type Entry struct {
+	Start       time.Time
+	End         time.Time // Zero for an entry that is still checked in
+	Account     string
+	Description string
+}
+
+// Duration returns the length of the entry, or zero if it is still checked in
+func (e Entry) Duration() time.Duration {
+	if e.End.IsZero() {
+		return 0
+	}
+	return e.End.Sub(e.Start)
+}
+
+// Projects returns the +project words of the description
+func (e Entry) Projects() []string {
+	return prefixedWords(e.Description, "+")
+}
+
+// Contexts returns the @context words of the description
+func (e Entry) Contexts() []string {
+	return prefixedWords(e.Description, "@")
+}
+
+func prefixedWords(s, prefix string) []string {
+	var words []string
+	for _, word := range strings.Fields(s) {
+		if strings.HasPrefix(word, prefix) && len(word) > len(prefix) {
+			words = append(words, word[len(prefix):])
+		}
+	}
+	return words
+}
+
+// String renders the entry as check-in and check-out lines
+func (e Entry) String() string {
+	account := e.Account
+	if account == "" {
+		account = DefaultAccount
+	}
+	line := "i " + e.Start.Local().Format(Layout) + " " + account
+	if e.Description != "" {
+		line += "  " + e.Description
+	}
+	line += "\n"
+	if !e.End.IsZero() {
+		line += "o " + e.End.Local().Format(Layout) + "\n"
+	}
+	return line
+}
+
+// Format renders entries as a timeclock file
+func Format(entries []Entry) string {
+	var sb strings.Builder
+	for _, e := range entries {
+		sb.WriteString(e.String())
+	}
+	return sb.String()
+}
+
+// ReadFile reads the entries of a timeclock file. A missing file has no entries.
+func ReadFile(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	return entries, nil
+}
+
+// Parse reads timeclock entries. Comment lines starting with ;, # or * are skipped.
+func Parse(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var open *Entry
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.ContainsAny(line[:1], ";#*") {
+			continue
+		}
+
+		code, rest, _ := strings.Cut(line, " ")
+		fields := strings.Fields(rest)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: missing date and time", n)
+		}
+		at, err := parseTime(fields[0], fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+
+		switch code {
+		case "i":
+			if open != nil {
+				return nil, fmt.Errorf("line %d: check-in while checked in since %s", n, open.Start.Format(Layout))
+			}
+			entry := Entry{Start: at}
+			// The account ends at two spaces or a tab, the description follows
+			rest = strings.TrimSpace(rest)
+			rest = strings.TrimSpace(rest[len(fields[0]):])
+			rest = strings.TrimSpace(rest[len(fields[1]):])
+			entry.Account, entry.Description = splitAccount(rest)
+			entries = append(entries, entry)
+			open = &entries[len(entries)-1]
+		case "o", "O":
+			if open == nil {
+				return nil, fmt.Errorf("line %d: check-out without check-in", n)
+			}
+			if at.Before(open.Start) {
+				return nil, fmt.Errorf("line %d: check-out before check-in", n)
+			}
+			open.End = at
+			open = nil
+		default:
+			// Other codes like h and b hold no time spans
+		}
+	}
+	return entries, scanner.Err()
+}
+
+func splitAccount(s string) (account, description string) {
+	for _, sep := range []string{"\t", "  "} {
+		if i := strings.Index(s, sep); i >= 0 {
+			return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i:])
+		}
+	}
+	return s, ""
+}
+
+func parseTime(date, clock string) (time.Time, error) {
+	date = strings.NewReplacer("-", "/", ".", "/").Replace(date)
+	for _, layout := range []string{Layout, "2006/01/02 15:04"} {
+		if t, err := time.ParseInLocation(layout, date+" "+clock, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date and time %q", date+" "+clock)
+}
+
+// Overlap is a pair of entries whose time spans intersect
+type Overlap struct {
+	Entry    Entry
+	Existing Entry
+}
+
+// Overlaps returns the entries that overlap an existing entry. Existing entries that are still
+// checked in are considered to last until now.
+func Overlaps(existing, entries []Entry) []Overlap {
+	sorted := make([]Entry, len(existing))
+	copy(sorted, existing)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+
+	var overlaps []Overlap
+	for _, e := range entries {
+		for _, x := range sorted {
+			end := x.End
+			if end.IsZero() {
+				end = time.Now()
+			}
+			if x.Start.Before(e.End) && e.Start.Before(end) {
+				overlaps = append(overlaps, Overlap{Entry: e, Existing: x})
+				break
+			}
+		}
+	}
+	return overlaps
+}
+
+// AppendFile appends entries to a timeclock file, creating it if necessary
+func AppendFile(path string, entries []Entry) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(Format(entries)); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
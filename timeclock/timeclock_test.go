@@ -0,0 +1,133 @@
+package timeclock
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func at(hour, minute int) time.Time {
+	return time.Date(2024, 10, 19, hour, minute, 0, 0, time.Local)
+}
+
+func TestParse(t *testing.T) {
+	content := "; imported\n" +
+		"i 2024/10/19 09:00:00 thesis  Write chapter +thesis\n" +
+		"o 2024/10/19 10:30:00\n" +
+		"i 2024-10-19 11:00 admin\n" +
+		"o 2024-10-19 11:15\n" +
+		"i 2024/10/19 14:00:00 thesis\n"
+	entries, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse() failed: %v", err)
+	}
+	want := []Entry{
+		{Start: at(9, 0), End: at(10, 30), Account: "thesis", Description: "Write chapter +thesis"},
+		{Start: at(11, 0), End: at(11, 15), Account: "admin"},
+		{Start: at(14, 0), Account: "thesis"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("Parse() = %+v, want %+v", entries, want)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+
+	formatted := "i 2024/10/19 09:00:00 thesis  Write chapter +thesis\no 2024/10/19 10:30:00\n"
+	if got := Format(entries[:1]); got != formatted {
+		t.Errorf("Format() = %q, want %q", got, formatted)
+	}
+
+	if _, err := Parse(strings.NewReader("o 2024/10/19 10:30:00\n")); err == nil {
+		t.Errorf("Parse() of check-out without check-in succeeded")
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	existing := []Entry{{Start: at(9, 0), End: at(10, 0), Account: "thesis"}}
+	entries := []Entry{
+		{Start: at(8, 0), End: at(9, 0)},
+		{Start: at(9, 30), End: at(11, 0)},
+		{Start: at(10, 0), End: at(11, 0)},
+	}
+	overlaps := Overlaps(existing, entries)
+	if len(overlaps) != 1 || overlaps[0].Entry != entries[1] {
+		t.Errorf("Overlaps() = %+v, want only the entry from 9:30", overlaps)
+	}
+}
+
+func TestImporters(t *testing.T) {
+	watson := `[{"id": "a1", "project": "thesis", "start": "2024-10-19T09:00:00Z", "stop": "2024-10-19T10:00:00Z", "tags": ["writing"]}]`
+	timewarrior := `[{"id": 1, "start": "20241019T090000Z", "end": "20241019T100000Z", "tags": ["thesis", "writing", "Write chapter"]},
+		{"id": 2, "start": "20241019T110000Z", "tags": ["running"]}]`
+	toggl := "User,Email,Client,Project,Task,Description,Billable,Start date,Start time,End date,End time,Duration,Tags\n" +
+		"Me,me@example.org,,thesis,,Write chapter,No,2024-10-19,09:00:00,2024-10-19,10:00:00,01:00:00,\"writing, deep work\"\n"
+
+	tests := []struct {
+		importer Importer
+		input    string
+		want     Entry
+	}{
+		{ImportWatson, watson, Entry{Account: "thesis", Description: "+thesis @writing"}},
+		{ImportTimewarrior, timewarrior, Entry{Account: "thesis", Description: "Write chapter +thesis @writing"}},
+		{ImportTogglCSV, toggl, Entry{Account: "thesis", Description: "Write chapter +thesis @writing @deep-work"}},
+	}
+	for i, tt := range tests {
+		entries, err := tt.importer(strings.NewReader(tt.input))
+		if err != nil {
+			t.Errorf("importer %d failed: %v", i, err)
+			continue
+		}
+		if len(entries) != 1 {
+			t.Errorf("importer %d returned %+v, want one entry", i, entries)
+			continue
+		}
+		e := entries[0]
+		if e.Account != tt.want.Account || e.Description != tt.want.Description || e.Duration() != time.Hour {
+			t.Errorf("importer %d returned %+v, want %+v lasting an hour", i, e, tt.want)
+		}
+	}
+}
+
+func TestExporters(t *testing.T) {
+	entries := []Entry{
+		{Start: at(9, 0), End: at(10, 30), Account: "thesis", Description: "Write chapter +thesis @desk"},
+		{Start: at(11, 0), End: at(11, 30), Account: "thesis", Description: "Fix figures @desk"},
+		{Start: at(14, 0), Account: "admin"},
+	}
+	utc := func(hour, minute int) string {
+		return at(hour, minute).UTC().Format("20060102T150405Z")
+	}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"timewarrior", []string{
+			`"start": "` + utc(9, 0) + `"`, `"end": "` + utc(10, 30) + `"`,
+			`"thesis",`, `"desk"`, `"annotation": "Fix figures @desk"`,
+		}},
+		{"hledger-timedot", []string{"2024-10-19\nthesis  2.00  ; desk:\n"}},
+		{"ical", []string{
+			"BEGIN:VEVENT\r\n", "DTSTART:" + utc(9, 0) + "\r\n", "DTEND:" + utc(10, 30) + "\r\n",
+			"SUMMARY:Write chapter +thesis @desk\r\n", "CATEGORIES:thesis,desk\r\n", "END:VCALENDAR\r\n",
+		}},
+	}
+	for _, tt := range tests {
+		var sb strings.Builder
+		if err := Exporters[tt.format](&sb, entries); err != nil {
+			t.Fatalf("%s export failed: %v", tt.format, err)
+		}
+		got := sb.String()
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s export = %q, want it to contain %q", tt.format, got, want)
+			}
+		}
+		if strings.Contains(got, "admin") {
+			t.Errorf("%s export = %q, want the running entry skipped", tt.format, got)
+		}
+	}
+}
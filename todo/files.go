@@ -0,0 +1,166 @@
+package todo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	todo "github.com/1set/todotxt"
+)
+
+// RouteConfig chooses the file new tasks matching a filter are added to
+type RouteConfig struct {
+	// Filter selects the tasks, see ParseFilter
+	Filter []string `mapstructure:"filter"`
+	// To is the file the tasks are added to, it must be one of the aggregated files
+	To string `mapstructure:"to"`
+}
+
+// Aggregate is the union of the tasks of several todo files. It remembers the file each task came
+// from, so changes can be written back to it.
+//
+// Stub tasks pointing to a split file that is part of the aggregate are not included in Tasks,
+// as their tasks already are, but they are kept in their files.
+type Aggregate struct {
+	Files []string
+	Tasks todo.TaskList
+
+	routes  []route
+	sources map[int]int    // Index into Files by task ID
+	stubs   map[int][]stub // Hidden stub tasks by index into Files
+	exists  []bool
+}
+
+// stub is a hidden stub task and the number of tasks preceding it in its file
+type stub struct {
+	task  todo.Task
+	after int
+}
+
+type route struct {
+	filter todo.Predicate
+	file   int
+}
+
+// ReadTodoFiles reads the todo files into an aggregate. Files that do not exist are treated as empty.
+// New tasks are added to the file of the first matching route, or to the first file.
+func ReadTodoFiles(files []string, routes []RouteConfig) (*Aggregate, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no todo files given")
+	}
+	a := &Aggregate{
+		Files:   files,
+		Tasks:   todo.NewTaskList(),
+		sources: make(map[int]int),
+		stubs:   make(map[int][]stub),
+		exists:  make([]bool, len(files)),
+	}
+
+	for _, config := range routes {
+		filter, err := ParseFilter(config.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid route to %s: %v", config.To, err)
+		}
+		file := a.fileIndex(config.To)
+		if file < 0 {
+			return nil, fmt.Errorf("route to %s: file is not one of the todo files", config.To)
+		}
+		a.routes = append(a.routes, route{filter: filter, file: file})
+	}
+
+	for i, path := range files {
+		taskList, err := readTodoFileIfExists(path)
+		if err != nil {
+			return nil, err
+		}
+		_, statErr := os.Stat(path)
+		a.exists[i] = statErr == nil
+
+		count := 0
+		for j := range taskList {
+			task := &taskList[j]
+			if see, isStub := task.AdditionalTags[SeeTag]; isStub && a.fileIndex(ResolveSplitPath(path, see)) >= 0 {
+				a.stubs[i] = append(a.stubs[i], stub{task: *task, after: count})
+				continue
+			}
+			a.Tasks.AddTask(task)
+			a.sources[task.ID] = i
+			count++
+		}
+	}
+	return a, nil
+}
+
+// Source returns the file a task came from, or the file it will be added to if it is new
+func (a *Aggregate) Source(task *todo.Task) string {
+	return a.Files[a.fileOf(task)]
+}
+
+// AddTask adds a new task, to be written to the file chosen by the routes
+func (a *Aggregate) AddTask(task *todo.Task) string {
+	a.Tasks.AddTask(task)
+	file := a.route(task)
+	a.sources[task.ID] = file
+	return a.Files[file]
+}
+
+// Write writes every task back to the file it came from and new tasks to the file chosen by the
+// routes. Files that did not exist and have no tasks are not created.
+func (a *Aggregate) Write() error {
+	lists := make([]todo.TaskList, len(a.Files))
+	for i := range a.Tasks {
+		file := a.fileOf(&a.Tasks[i])
+		lists[file] = append(lists[file], a.Tasks[i])
+	}
+
+	for i, path := range a.Files {
+		// Stubs keep their position among the remaining tasks
+		taskList := todo.NewTaskList()
+		stubs := a.stubs[i]
+		for j := 0; j <= len(lists[i]); j++ {
+			for len(stubs) > 0 && (stubs[0].after <= j || j == len(lists[i])) {
+				taskList.AddTask(&stubs[0].task)
+				stubs = stubs[1:]
+			}
+			if j < len(lists[i]) {
+				taskList.AddTask(&lists[i][j])
+			}
+		}
+		if len(taskList) == 0 && !a.exists[i] {
+			continue
+		}
+		if err := WriteTodoFile(taskList, path); err != nil {
+			return err
+		}
+		a.exists[i] = true
+	}
+	return nil
+}
+
+// fileOf returns the index of the file a task belongs to
+func (a *Aggregate) fileOf(task *todo.Task) int {
+	if file, ok := a.sources[task.ID]; ok {
+		return file
+	}
+	return a.route(task)
+}
+
+// route returns the index of the file a new task is added to
+func (a *Aggregate) route(task *todo.Task) int {
+	for _, r := range a.routes {
+		if r.filter(*task) {
+			return r.file
+		}
+	}
+	return 0
+}
+
+// fileIndex returns the index of path in Files, or -1
+func (a *Aggregate) fileIndex(path string) int {
+	for i, file := range a.Files {
+		if filepath.Clean(file) == filepath.Clean(path) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,61 @@
+package todo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	todo "github.com/1set/todotxt"
+)
+
+func TestAggregate(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "todo.txt")
+	work := filepath.Join(dir, "work.txt")
+	home := filepath.Join(dir, "home.txt")
+	os.WriteFile(main, []byte("Call mom\nTasks moved to work.txt +work see:work.txt\n"), 0640)
+	os.WriteFile(work, []byte("Write report +work\n"), 0640)
+
+	a, err := ReadTodoFiles([]string{main, work, home}, []RouteConfig{
+		{Filter: []string{"+work"}, To: work},
+		{Filter: []string{"@home"}, To: home},
+	})
+	if err != nil {
+		t.Fatalf("ReadTodoFiles() failed: %v", err)
+	}
+	if len(a.Tasks) != 2 {
+		t.Fatalf("ReadTodoFiles() has %d tasks, want 2 without the stub", len(a.Tasks))
+	}
+	if got := a.Source(&a.Tasks[1]); got != work {
+		t.Errorf("Source(%s) = %s, want %s", a.Tasks[1].Todo, got, work)
+	}
+
+	a.Tasks[1].Complete()
+	for _, line := range []string{"Review PR +work", "Water plants @home", "Buy milk"} {
+		task, _ := todo.ParseTask(line)
+		a.AddTask(task)
+	}
+	if err := a.Write(); err != nil {
+		t.Fatalf("Write() failed: %v", err)
+	}
+
+	tests := []struct {
+		path, want string
+	}{
+		{main, "Call mom\nTasks moved to work.txt +work see:work.txt\nBuy milk\n"},
+		{work, a.Tasks[1].String() + "\nReview PR +work\n"},
+		{home, "Water plants @home\n"},
+	}
+	for _, tt := range tests {
+		content, _ := os.ReadFile(tt.path)
+		if string(content) != tt.want {
+			t.Errorf("%s = %q, want %q", filepath.Base(tt.path), content, tt.want)
+		}
+	}
+}
+
+func TestAggregateInvalidRoute(t *testing.T) {
+	if _, err := ReadTodoFiles([]string{"todo.txt"}, []RouteConfig{{Filter: []string{"+work"}, To: "work.txt"}}); err == nil {
+		t.Error("ReadTodoFiles() with route to unknown file succeeded")
+	}
+}
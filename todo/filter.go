@@ -0,0 +1,63 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+
+	todo "github.com/1set/todotxt"
+)
+
+// ParseFilter builds a predicate from filter terms. All terms must match:
+//   - +project matches tasks of the project
+//   - @context matches tasks in the context
+//   - key:value matches tasks with the additional tag
+//   - any other word matches tasks whose text contains it
+//
+// String comparison is case-insensitive, except for tag values.
+func ParseFilter(terms []string) (todo.Predicate, error) {
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("empty filter")
+	}
+
+	var predicates []todo.Predicate
+	for _, term := range terms {
+		for _, word := range strings.Fields(term) {
+			p, err := parseFilterWord(word)
+			if err != nil {
+				return nil, err
+			}
+			predicates = append(predicates, p)
+		}
+	}
+
+	return func(t todo.Task) bool {
+		for _, p := range predicates {
+			if !p(t) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func parseFilterWord(word string) (todo.Predicate, error) {
+	switch {
+	case strings.HasPrefix(word, "+") && len(word) > 1:
+		return todo.FilterByProject(word[1:]), nil
+	case strings.HasPrefix(word, "@") && len(word) > 1:
+		return todo.FilterByContext(word[1:]), nil
+	case strings.Contains(word, ":"):
+		parts := strings.SplitN(word, ":", 2)
+		if parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid tag filter %q, expected key:value", word)
+		}
+		return func(t todo.Task) bool {
+			value, exists := t.AdditionalTags[parts[0]]
+			return exists && value == parts[1]
+		}, nil
+	}
+	lower := strings.ToLower(word)
+	return func(t todo.Task) bool {
+		return strings.Contains(strings.ToLower(t.Todo), lower)
+	}, nil
+}
@@ -0,0 +1,81 @@
+package todo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/adrg/xdg"
+)
+
+// FileLock is an exclusive lock on a todo file, guarding a read-modify-write of it against other
+// processes like t serve or t sync live. It is only honored by code taking the lock as well.
+type FileLock struct {
+	file *os.File
+}
+
+// LockFile waits until it holds the lock on the todo file at path. The lock is taken on a separate
+// file in the XDG state directory, as sync backends replace the todo file itself.
+func LockFile(path string) (*FileLock, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, &FileError{Op: "lock", Path: path, Err: err}
+	}
+	sum := sha256.Sum256([]byte(path))
+	lockPath, err := xdg.StateFile(filepath.Join("t", "locks", hex.EncodeToString(sum[:8])+".lock"))
+	if err != nil {
+		return nil, &FileError{Op: "lock", Path: path, Err: fmt.Errorf("error locating lock file: %v", err)}
+	}
+
+	file, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, &FileError{Op: "lock", Path: path, Err: err}
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, &FileError{Op: "lock", Path: path, Err: err}
+	}
+	return &FileLock{file: file}, nil
+}
+
+// Unlock releases the lock
+func (l *FileLock) Unlock() error {
+	unlockFile(l.file)
+	return l.file.Close()
+}
+
+// LockFiles locks several todo files in a fixed order, so processes locking overlapping sets
+// cannot deadlock. The returned function releases all locks.
+func LockFiles(paths []string) (func(), error) {
+	sorted := make([]string, len(paths))
+	for i, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, &FileError{Op: "lock", Path: path, Err: err}
+		}
+		sorted[i] = abs
+	}
+	sort.Strings(sorted)
+
+	var locks []*FileLock
+	unlock := func() {
+		for _, lock := range locks {
+			lock.Unlock()
+		}
+	}
+	for i, path := range sorted {
+		if i > 0 && path == sorted[i-1] {
+			continue
+		}
+		lock, err := LockFile(path)
+		if err != nil {
+			unlock()
+			return nil, err
+		}
+		locks = append(locks, lock)
+	}
+	return unlock, nil
+}
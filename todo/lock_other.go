@@ -0,0 +1,12 @@
+//go:build !unix
+
+package todo
+
+import "os"
+
+// Systems without flock get no locking between processes
+func lockFile(file *os.File) error {
+	return nil
+}
+
+func unlockFile(file *os.File) {}
@@ -0,0 +1,43 @@
+package todo
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adrg/xdg"
+)
+
+func TestLockFile(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	xdg.Reload()
+
+	path := filepath.Join(t.TempDir(), "todo.txt")
+	lock, err := LockFile(path)
+	if err != nil {
+		t.Fatalf("LockFile() failed: %v", err)
+	}
+
+	locked := make(chan struct{})
+	go func() {
+		unlock, err := LockFiles([]string{path, path})
+		if err != nil {
+			t.Errorf("LockFiles() failed: %v", err)
+		} else {
+			unlock()
+		}
+		close(locked)
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("second lock was acquired while the first was held")
+	case <-time.After(50 * time.Millisecond):
+	}
+	lock.Unlock()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("second lock was not acquired after unlocking")
+	}
+}
@@ -0,0 +1,485 @@
+package todo
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	todo "github.com/1set/todotxt"
+)
+
+// FuzzyMatchThreshold is the minimum similarity of two task texts for them to be
+// considered the same task when neither ID nor URL match
+var FuzzyMatchThreshold = 0.9
+
+// MergeConflict describes a field that was changed differently on both sides
+type MergeConflict struct {
+	Task   string // Text of the task, as merged
+	Field  string // Name of the conflicting field, e.g. due or a tag name like state
+	Ours   string
+	Theirs string
+}
+
+// MergeResult contains statistics about a merge operation
+type MergeResult struct {
+	Matched   int // Tasks found on both sides
+	Added     int // Tasks only found on their side
+	Deleted   int // Tasks deleted on one side and unchanged on the other
+	Conflicts []MergeConflict
+}
+
+// MergeTaskLists merges their tasks into ours, using base as the common ancestor if it is not nil.
+//
+// Tasks are matched by their id or uuid tag, then by their url tag, and finally by similar text.
+// Matched tasks are merged field by field: a completion on either side wins, a field changed on
+// only one side takes that change, and a field changed on both sides takes the value of the side
+// with the newer modified tag. If the modified tags cannot decide, our value is kept and the field
+// is reported as a conflict. Without a common ancestor, a field set on only one side is kept.
+//
+// Without a base, tasks found on only one side are kept. With a base, a task deleted on one side
+// is dropped if the other side left it unchanged, and kept and reported as a conflict otherwise.
+func MergeTaskLists(base, ours, theirs todo.TaskList) (todo.TaskList, *MergeResult) {
+	merged, result, _ := mergeTaskLists(base, ours, theirs)
+	return merged, result
+}
+
+// mergeTaskLists merges like MergeTaskLists and also returns the index in ours each merged task
+// came from, or -1 for tasks only found on their side
+func mergeTaskLists(base, ours, theirs todo.TaskList) (todo.TaskList, *MergeResult, []int) {
+	result := &MergeResult{}
+	merged := todo.NewTaskList()
+	var origins []int
+
+	theirsUsed := make([]bool, len(theirs))
+	baseUsed := make([]bool, len(base))
+	for i := range ours {
+		our := &ours[i]
+		j := matchTask(theirs, theirsUsed, our)
+		k := -1
+		if base != nil {
+			k = matchTask(base, baseUsed, our)
+		}
+
+		if j < 0 {
+			if k >= 0 {
+				baseUsed[k] = true
+			}
+			if k >= 0 && taskUnchanged(our, &base[k]) {
+				// Deleted on their side and unchanged on ours
+				result.Deleted++
+				continue
+			}
+			if k >= 0 {
+				result.Conflicts = append(result.Conflicts, MergeConflict{Task: our.Todo, Field: "deleted", Ours: "changed", Theirs: "deleted"})
+			}
+			merged.AddTask(our)
+			origins = append(origins, i)
+			continue
+		}
+
+		theirsUsed[j] = true
+		var ancestor *todo.Task
+		if k < 0 && base != nil {
+			k = matchTask(base, baseUsed, &theirs[j])
+		}
+		if k >= 0 {
+			baseUsed[k] = true
+			ancestor = &base[k]
+		}
+
+		task, conflicts := mergeTask(ancestor, our, &theirs[j])
+		result.Matched++
+		result.Conflicts = append(result.Conflicts, conflicts...)
+		merged.AddTask(&task)
+		origins = append(origins, i)
+	}
+
+	for j := range theirs {
+		if theirsUsed[j] {
+			continue
+		}
+		their := &theirs[j]
+		if base != nil {
+			if k := matchTask(base, baseUsed, their); k >= 0 {
+				baseUsed[k] = true
+				if taskUnchanged(their, &base[k]) {
+					// Deleted on our side and unchanged on theirs
+					result.Deleted++
+					continue
+				}
+				result.Conflicts = append(result.Conflicts, MergeConflict{Task: their.Todo, Field: "deleted", Ours: "deleted", Theirs: "changed"})
+			}
+		}
+		merged.AddTask(their)
+		origins = append(origins, -1)
+		result.Added++
+	}
+
+	return merged, result, origins
+}
+
+// MergeContents merges the contents of two versions of a todo file like MergeTaskLists.
+// A nil base merges without common ancestor. Comment lines of our version are kept in front of
+// the task they preceded, or of the next remaining task if that one was deleted.
+func MergeContents(base, ours, theirs []byte) ([]byte, *MergeResult, error) {
+	var baseList todo.TaskList
+	if base != nil {
+		var err error
+		if baseList, err = ParseTaskList(base); err != nil {
+			return nil, nil, fmt.Errorf("error parsing base version: %v", err)
+		}
+	}
+	ourList, comments, err := parseTaskListWithComments(ours)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing our version: %v", err)
+	}
+	theirList, err := ParseTaskList(theirs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing their version: %v", err)
+	}
+
+	merged, result, origins := mergeTaskLists(baseList, ourList, theirList)
+
+	var sb strings.Builder
+	next := 0 // Index of the next task in ours whose comments were not written yet
+	writeComments := func(upTo int) {
+		for ; next < upTo; next++ {
+			for _, comment := range comments[next] {
+				sb.WriteString(comment + "\n")
+			}
+		}
+	}
+	for i, task := range merged {
+		if origins[i] >= 0 {
+			writeComments(origins[i] + 1)
+		} else {
+			writeComments(len(ourList))
+		}
+		sb.WriteString(task.String() + "\n")
+	}
+	writeComments(len(comments))
+	return []byte(sb.String()), result, nil
+}
+
+// MergeFiles merges the todo files at base, ours and theirs like MergeContents and writes the
+// result to ours, as a git merge driver does with %O %A %B
+func MergeFiles(base, ours, theirs string) (*MergeResult, error) {
+	var contents [3][]byte
+	for i, path := range []string{base, ours, theirs} {
+		var err error
+		if contents[i], err = os.ReadFile(path); err != nil {
+			return nil, &FileError{Op: "read", Path: path, Err: err}
+		}
+	}
+	merged, result, err := MergeContents(contents[0], contents[1], contents[2])
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(ours, merged, 0644); err != nil {
+		return nil, &FileError{Op: "write", Path: ours, Err: err}
+	}
+	return result, nil
+}
+
+// MergeTask merges two versions of the same task without common ancestor, field by field like MergeTaskLists
+func MergeTask(ours, theirs *todo.Task) (todo.Task, []MergeConflict) {
+	return mergeTask(nil, ours, theirs)
+}
+
+// MergeTaskWithBase merges two versions of the same task with their common ancestor, field by
+// field like MergeTaskLists
+func MergeTaskWithBase(base, ours, theirs *todo.Task) (todo.Task, []MergeConflict) {
+	return mergeTask(base, ours, theirs)
+}
+
+// matchTask returns the index of the first unused task in taskList that is the same task,
+// matching by ID, then by URL and then by similar text. It returns -1 if there is none.
+func matchTask(taskList todo.TaskList, used []bool, task *todo.Task) int {
+	id, hasID := TaskIdentifier(task)
+	if hasID {
+		for i := range taskList {
+			if otherID, ok := TaskIdentifier(&taskList[i]); ok && !used[i] && otherID == id {
+				return i
+			}
+		}
+	}
+
+	url, hasURL := task.AdditionalTags["url"]
+	if hasURL {
+		for i := range taskList {
+			// Tasks with different IDs are different tasks, even with the same URL
+			if _, ok := TaskIdentifier(&taskList[i]); ok && hasID {
+				continue
+			}
+			if otherURL, ok := taskList[i].AdditionalTags["url"]; ok && !used[i] && otherURL == url {
+				return i
+			}
+		}
+	}
+
+	best, bestScore := -1, FuzzyMatchThreshold
+	for i := range taskList {
+		if used[i] {
+			continue
+		}
+		// Tasks with different IDs or URLs are different tasks, however similar their text
+		if _, ok := TaskIdentifier(&taskList[i]); ok && hasID {
+			continue
+		}
+		if _, ok := taskList[i].AdditionalTags["url"]; ok && hasURL {
+			continue
+		}
+		if score := similarity(task.Todo, taskList[i].Todo); score >= bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// MatchTaskText returns the index of the task whose text is the most similar to text, if it
+// reaches FuzzyMatchThreshold, or -1. Projects, contexts and tags in text are ignored.
+func MatchTaskText(taskList todo.TaskList, text string) int {
+	parsed, err := todo.ParseTask(text)
+	if err != nil || parsed.Todo == "" {
+		return -1
+	}
+	best, bestScore := -1, FuzzyMatchThreshold
+	for i := range taskList {
+		if score := similarity(parsed.Todo, taskList[i].Todo); score >= bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// mergeTask merges two versions of the same task field by field
+func mergeTask(base, ours, theirs *todo.Task) (todo.Task, []MergeConflict) {
+	ourFields, theirFields := taskFields(ours), taskFields(theirs)
+	baseFields := map[string]string{}
+	if base != nil {
+		baseFields = taskFields(base)
+	}
+
+	// Newer modified tag decides fields changed on both sides
+	ourModified, theirModified := modifiedTime(ours), modifiedTime(theirs)
+	preferTheirs := theirModified.After(ourModified)
+	decided := !ourModified.Equal(theirModified)
+
+	keys := make(map[string]bool)
+	for key := range ourFields {
+		keys[key] = true
+	}
+	for key := range theirFields {
+		keys[key] = true
+	}
+
+	// IDs are equal if the tasks matched by ID, only their encoding may differ
+	delete(keys, "tag:id")
+	delete(keys, "tag:uuid")
+
+	var conflicts []MergeConflict
+	merged := make(map[string]string)
+	for key := range keys {
+		our, their := ourFields[key], theirFields[key]
+		switch {
+		case our == their:
+			merged[key] = our
+		case key == "tag:modified":
+			if preferTheirs {
+				merged[key] = their
+			} else {
+				merged[key] = our
+			}
+		case base != nil && our == baseFields[key]:
+			merged[key] = their
+		case base != nil && their == baseFields[key]:
+			merged[key] = our
+		case base == nil && our == "":
+			// Without a common ancestor a field set on only one side was most likely added there
+			merged[key] = their
+		case base == nil && their == "":
+			merged[key] = our
+		case decided && preferTheirs:
+			merged[key] = their
+		case decided:
+			merged[key] = our
+		default:
+			merged[key] = our
+			conflicts = append(conflicts, MergeConflict{Field: strings.TrimPrefix(key, "tag:"), Ours: our, Theirs: their})
+		}
+	}
+
+	task := taskFromFields(merged)
+	task.ID = ours.ID
+
+	// A completion on either side wins
+	switch {
+	case ours.Completed && theirs.Completed:
+		task.Completed = true
+		task.CompletedDate = earliestDate(ours.CompletedDate, theirs.CompletedDate)
+	case ours.Completed:
+		task.Completed, task.CompletedDate = true, ours.CompletedDate
+	case theirs.Completed:
+		task.Completed, task.CompletedDate = true, theirs.CompletedDate
+	}
+
+	// Keep our ID in our encoding, or take theirs if we have none
+	idSource := ours
+	if _, ok := TaskIdentifier(ours); !ok {
+		idSource = theirs
+	}
+	for _, key := range []string{"id", "uuid"} {
+		if value, exists := idSource.AdditionalTags[key]; exists {
+			task.AdditionalTags[key] = value
+		}
+	}
+
+	for i := range conflicts {
+		conflicts[i].Task = task.Todo
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Field < conflicts[j].Field })
+	return task, conflicts
+}
+
+// taskFields flattens the mergeable fields of a task into strings. Completion is merged separately.
+func taskFields(task *todo.Task) map[string]string {
+	fields := map[string]string{
+		"text":     task.Todo,
+		"priority": task.Priority,
+		"projects": joinSorted(task.Projects),
+		"contexts": joinSorted(task.Contexts),
+		"created":  formatDate(task.CreatedDate),
+		"due":      formatDate(task.DueDate),
+	}
+	for key, value := range task.AdditionalTags {
+		fields["tag:"+key] = value
+	}
+	return fields
+}
+
+// taskFromFields builds a task from fields created by taskFields
+func taskFromFields(fields map[string]string) todo.Task {
+	task := todo.Task{
+		Todo:           fields["text"],
+		Priority:       fields["priority"],
+		Projects:       splitNonEmpty(fields["projects"]),
+		Contexts:       splitNonEmpty(fields["contexts"]),
+		CreatedDate:    parseDate(fields["created"]),
+		DueDate:        parseDate(fields["due"]),
+		AdditionalTags: make(map[string]string),
+	}
+	for key, value := range fields {
+		if strings.HasPrefix(key, "tag:") && value != "" {
+			task.AdditionalTags[strings.TrimPrefix(key, "tag:")] = value
+		}
+	}
+	return task
+}
+
+// taskUnchanged reports whether a task is equal to its base version
+func taskUnchanged(task, base *todo.Task) bool {
+	return task.Completed == base.Completed && fieldsEqual(taskFields(task), taskFields(base))
+}
+
+func fieldsEqual(a, b map[string]string) bool {
+	for key, value := range a {
+		if b[key] != value {
+			return false
+		}
+	}
+	for key, value := range b {
+		if a[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// modifiedTime returns the time of the modified tag of a task, or the zero time
+func modifiedTime(task *todo.Task) time.Time {
+	modified, err := time.Parse(time.RFC3339, task.AdditionalTags["modified"])
+	if err != nil {
+		return time.Time{}
+	}
+	return modified
+}
+
+func earliestDate(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(todo.DateLayout)
+}
+
+func parseDate(value string) time.Time {
+	date, err := time.ParseInLocation(todo.DateLayout, value, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return date
+}
+
+func joinSorted(values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, " ")
+}
+
+func splitNonEmpty(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, " ")
+}
+
+// similarity returns the similarity of two texts between 0 and 1,
+// based on the Levenshtein distance of their normalized forms
+func similarity(a, b string) float64 {
+	ra := []rune(strings.ToLower(strings.Join(strings.Fields(a), " ")))
+	rb := []rune(strings.ToLower(strings.Join(strings.Fields(b), " ")))
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
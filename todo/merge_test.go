@@ -0,0 +1,108 @@
+package todo
+
+import (
+	"strings"
+	"testing"
+
+	todo "github.com/1set/todotxt"
+)
+
+func parseTaskList(t *testing.T, lines ...string) todo.TaskList {
+	t.Helper()
+	taskList := todo.NewTaskList()
+	for _, line := range lines {
+		task, err := todo.ParseTask(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		taskList.AddTask(task)
+	}
+	return taskList
+}
+
+func TestMergeTaskLists(t *testing.T) {
+	base := parseTaskList(t,
+		"2024-10-01 Write chapter +thesis id:tI4JeTHbMqhXUS9Ig0Pg9t",
+		"2024-10-01 Review issue url:https://example.org/1",
+		"2024-10-01 Call the plumber about the sink",
+		"2024-10-01 Water plants",
+	)
+	ours := parseTaskList(t,
+		"2024-10-01 Write chapter +thesis id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01",
+		"x 2024-10-05 2024-10-01 Review issue url:https://example.org/1",
+		"2024-10-01 Call the plumber about the sink @phone",
+		"2024-10-01 Water plants",
+	)
+	theirs := parseTaskList(t,
+		"(A) 2024-10-01 Write chapter +thesis",
+		"2024-10-01 Review the issue url:https://example.org/1",
+		"2024-10-01 Call the plumber about the sinks",
+		"2024-10-02 Buy milk",
+	)
+	// Same task with its ID in long form
+	id, _ := TaskIdentifier(&ours[0])
+	theirs[0].AdditionalTags = map[string]string{"uuid": id.String()}
+
+	merged, result := MergeTaskLists(base, ours, theirs)
+
+	want := []string{
+		"(A) 2024-10-01 Write chapter +thesis id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01",
+		"x 2024-10-05 2024-10-01 Review the issue url:https://example.org/1",
+		"2024-10-01 Call the plumber about the sinks @phone",
+		"2024-10-02 Buy milk",
+	}
+	if got := strings.TrimSpace(merged.String()); got != strings.Join(want, "\n") {
+		t.Errorf("MergeTaskLists() =\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+	if result.Matched != 3 || result.Added != 1 || result.Deleted != 1 || len(result.Conflicts) != 0 {
+		t.Errorf("MergeTaskLists() result = %+v", result)
+	}
+}
+
+func TestMergeTaskListsConflicts(t *testing.T) {
+	ours := parseTaskList(t, "Submit report id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01")
+	theirs := parseTaskList(t, "Submit report id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-08")
+
+	_, result := MergeTaskLists(nil, ours, theirs)
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Field != "due" {
+		t.Fatalf("MergeTaskLists() conflicts = %+v, want one due conflict", result.Conflicts)
+	}
+
+	// A newer modified tag decides the conflict
+	theirs[0].AdditionalTags["modified"] = "2024-10-20T10:00:00Z"
+	merged, result := MergeTaskLists(nil, ours, theirs)
+	if len(result.Conflicts) != 0 {
+		t.Errorf("MergeTaskLists() conflicts = %+v, want none", result.Conflicts)
+	}
+	if got := merged[0].DueDate.Format(todo.DateLayout); got != "2024-11-08" {
+		t.Errorf("merged due date = %s, want 2024-11-08", got)
+	}
+
+	// Without base, fields set on only one side are kept
+	ours = parseTaskList(t, "Submit report id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01")
+	theirs = parseTaskList(t, "(A) Submit report id:tI4JeTHbMqhXUS9Ig0Pg9t est:2h")
+	merged, result = MergeTaskLists(nil, ours, theirs)
+	if len(result.Conflicts) != 0 {
+		t.Errorf("MergeTaskLists() conflicts = %+v, want none", result.Conflicts)
+	}
+	if got := merged[0].String(); got != "(A) Submit report est:2h id:tI4JeTHbMqhXUS9Ig0Pg9t due:2024-11-01" {
+		t.Errorf("merged task = %q, want both priority, due date and estimate", got)
+	}
+}
+
+func TestMergeTaskListsURLWithDifferentIDs(t *testing.T) {
+	// Two tasks for the same issue with different IDs stay separate tasks
+	ours := parseTaskList(t, "Fix login url:https://example.org/1 id:tI4JeTHbMqhXUS9Ig0Pg9t")
+	theirs := parseTaskList(t, "Fix login url:https://example.org/1 id:tI4JeTHbMqlSrWPjtn3Zzf")
+
+	merged, result := MergeTaskLists(nil, ours, theirs)
+	if len(merged) != 2 || result.Added != 1 {
+		t.Errorf("MergeTaskLists() = %q, want both tasks", merged.String())
+	}
+
+	// Without ID on one side the URL still matches
+	delete(theirs[0].AdditionalTags, "id")
+	if merged, _ := MergeTaskLists(nil, ours, theirs); len(merged) != 1 {
+		t.Errorf("MergeTaskLists() = %q, want one task", merged.String())
+	}
+}
@@ -0,0 +1,208 @@
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	todo "github.com/1set/todotxt"
+)
+
+// SeeTag is the additional tag of stub tasks pointing to a split file
+const SeeTag = "see"
+
+// SplitConfig describes a set of tasks to be kept in a separate file
+type SplitConfig struct {
+	// Filter selects the tasks to move, see ParseFilter
+	Filter []string `mapstructure:"filter"`
+	// To is the file the tasks are moved to. Relative paths are relative to the directory of the todo file.
+	To string `mapstructure:"to"`
+	// Stub determines whether a stub task pointing to the split file is kept in the todo file
+	Stub bool `mapstructure:"stub"`
+}
+
+// SplitResult contains statistics about a split or join operation
+type SplitResult struct {
+	Moved     int             // Tasks moved between the files
+	Updated   int             // Moved tasks that replaced an existing copy of the same task
+	Conflicts []MergeConflict // Fields of updated tasks that differed, the moved value was kept
+}
+
+// SplitTodoFile moves all tasks matching the split filter from the todo file at path into the split file.
+// Tasks that already exist in the split file, matched by ID or URL, are updated instead of duplicated.
+func SplitTodoFile(path string, config SplitConfig) (*SplitResult, error) {
+	filter, err := ParseFilter(config.Filter)
+	if err != nil {
+		return nil, err
+	}
+	if config.To == "" {
+		return nil, fmt.Errorf("no split file given")
+	}
+	splitPath := ResolveSplitPath(path, config.To)
+
+	taskList, err := ReadTodoFile(path)
+	if err != nil {
+		return nil, err
+	}
+	splitList, err := readTodoFileIfExists(splitPath)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := todo.NewTaskList()
+	moved := todo.NewTaskList()
+	hasStub := false
+	for _, task := range taskList {
+		if see, isStub := task.AdditionalTags[SeeTag]; isStub {
+			hasStub = hasStub || see == config.To
+			remaining.AddTask(&task)
+		} else if filter(task) {
+			moved.AddTask(&task)
+		} else {
+			remaining.AddTask(&task)
+		}
+	}
+
+	result := &SplitResult{Moved: len(moved)}
+	splitList, result.Updated, result.Conflicts = mergeMovedTasks(splitList, moved)
+
+	if config.Stub && !hasStub {
+		stub := newStubTask(config)
+		remaining.AddTask(&stub)
+	}
+
+	if err := WriteTodoFile(splitList, splitPath); err != nil {
+		return nil, err
+	}
+	if err := WriteTodoFile(remaining, path); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// JoinTodoFile moves all tasks from the split file back into the todo file at path,
+// removes stub tasks pointing to it and deletes the split file.
+func JoinTodoFile(path, from string) (*SplitResult, error) {
+	splitPath := ResolveSplitPath(path, from)
+
+	taskList, err := ReadTodoFile(path)
+	if err != nil {
+		return nil, err
+	}
+	splitList, err := ReadTodoFile(splitPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tasks that were added to the todo file again after the split, e.g. by a sync,
+	// are merged into their copies from the split file
+	remaining := todo.NewTaskList()
+	readded := todo.NewTaskList()
+	for _, task := range taskList {
+		if see, isStub := task.AdditionalTags[SeeTag]; isStub && see == from {
+			continue
+		}
+		if indexOfTask(splitList, &task) >= 0 {
+			readded.AddTask(&task)
+		} else {
+			remaining.AddTask(&task)
+		}
+	}
+
+	result := &SplitResult{Moved: len(splitList)}
+	splitList, result.Updated, result.Conflicts = mergeMovedTasks(splitList, readded)
+	for _, task := range splitList {
+		remaining.AddTask(&task)
+	}
+
+	if err := WriteTodoFile(remaining, path); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(splitPath); err != nil {
+		return nil, &FileError{Op: "remove", Path: splitPath, Err: err}
+	}
+	return result, nil
+}
+
+// mergeMovedTasks adds moved tasks to the target list and returns it along with the number of
+// moved tasks that replaced an existing copy and the merge conflicts. A moved task with the same ID or URL as a target
+// task is merged into it field by field, the moved version winning conflicts. The target task
+// keeps its ID and stays completed if it was. All other tasks are appended.
+func mergeMovedTasks(target, moved todo.TaskList) (todo.TaskList, int, []MergeConflict) {
+	replaced := 0
+	var conflicts []MergeConflict
+	for _, task := range moved {
+		i := indexOfTask(target, &task)
+		if i < 0 {
+			target.AddTask(&task)
+			continue
+		}
+
+		merged, taskConflicts := MergeTask(&task, &target[i])
+		conflicts = append(conflicts, taskConflicts...)
+		if _, hasID := TaskIdentifier(&target[i]); hasID {
+			delete(merged.AdditionalTags, "id")
+			delete(merged.AdditionalTags, "uuid")
+			for _, key := range []string{"id", "uuid"} {
+				if value, exists := target[i].AdditionalTags[key]; exists {
+					merged.AdditionalTags[key] = value
+				}
+			}
+		}
+		merged.ID = target[i].ID
+		target[i] = merged
+		replaced++
+	}
+	return target, replaced, conflicts
+}
+
+// indexOfTask returns the index of the task in taskList with the same ID or URL, or -1
+func indexOfTask(taskList todo.TaskList, task *todo.Task) int {
+	id, hasID := TaskIdentifier(task)
+	url, hasURL := task.AdditionalTags["url"]
+	for i := range taskList {
+		if otherID, ok := TaskIdentifier(&taskList[i]); ok && hasID && otherID == id {
+			return i
+		}
+		if otherURL, ok := taskList[i].AdditionalTags["url"]; ok && hasURL && otherURL == url {
+			return i
+		}
+	}
+	return -1
+}
+
+// newStubTask creates the task that points from the todo file to a split file
+func newStubTask(config SplitConfig) todo.Task {
+	stub := todo.NewTask()
+	stub.Todo = "Tasks moved to " + config.To
+	for _, term := range config.Filter {
+		for _, word := range strings.Fields(term) {
+			if strings.HasPrefix(word, "+") && len(word) > 1 {
+				stub.Projects = append(stub.Projects, word[1:])
+			} else if strings.HasPrefix(word, "@") && len(word) > 1 {
+				stub.Contexts = append(stub.Contexts, word[1:])
+			}
+		}
+	}
+	stub.AdditionalTags = map[string]string{SeeTag: config.To}
+	return stub
+}
+
+// ResolveSplitPath resolves the path of a split file relative to the directory of the todo file
+func ResolveSplitPath(todoPath, splitPath string) string {
+	if filepath.IsAbs(splitPath) {
+		return splitPath
+	}
+	return filepath.Join(filepath.Dir(todoPath), splitPath)
+}
+
+// readTodoFileIfExists reads a todo file, returning an empty TaskList if it does not exist
+func readTodoFileIfExists(path string) (todo.TaskList, error) {
+	taskList, err := ReadTodoFile(path)
+	if err != nil && errors.Is(err, os.ErrNotExist) {
+		return todo.NewTaskList(), nil
+	}
+	return taskList, err
+}
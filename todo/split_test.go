@@ -0,0 +1,88 @@
+package todo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitAndJoinTodoFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "todo.txt")
+	original := "2024-10-01 Write chapter +thesis id:tI4JeTHbMqhXUS9Ig0Pg9t\n" +
+		"2024-10-02 Buy milk @shop\n"
+	if err := os.WriteFile(path, []byte(original), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := SplitTodoFile(path, SplitConfig{Filter: []string{"+thesis"}, To: "thesis.txt", Stub: true})
+	if err != nil {
+		t.Fatalf("SplitTodoFile() failed: %v", err)
+	}
+	if result.Moved != 1 {
+		t.Errorf("SplitTodoFile() moved %d tasks, want 1", result.Moved)
+	}
+
+	main := readFile(t, path)
+	if strings.Contains(main, "Write chapter") || !strings.Contains(main, "see:thesis.txt") {
+		t.Errorf("todo file after split = %q, want stub instead of moved task", main)
+	}
+	split := readFile(t, filepath.Join(dir, "thesis.txt"))
+	if !strings.Contains(split, "Write chapter +thesis id:tI4JeTHbMqhXUS9Ig0Pg9t") {
+		t.Errorf("split file = %q, want moved task with its id", split)
+	}
+
+	// Splitting again must not add a second stub
+	if _, err := SplitTodoFile(path, SplitConfig{Filter: []string{"+thesis"}, To: "thesis.txt", Stub: true}); err != nil {
+		t.Fatalf("SplitTodoFile() failed: %v", err)
+	}
+	if n := strings.Count(readFile(t, path), "see:"); n != 1 {
+		t.Errorf("todo file has %d stubs, want 1", n)
+	}
+
+	if _, err := JoinTodoFile(path, "thesis.txt"); err != nil {
+		t.Fatalf("JoinTodoFile() failed: %v", err)
+	}
+	if joined := readFile(t, path); joined != "2024-10-02 Buy milk @shop\n"+
+		"2024-10-01 Write chapter +thesis id:tI4JeTHbMqhXUS9Ig0Pg9t\n" {
+		t.Errorf("todo file after join = %q", joined)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "thesis.txt")); !os.IsNotExist(err) {
+		t.Errorf("split file still exists after join")
+	}
+}
+
+func TestSplitMergesReaddedTasks(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "todo.txt")
+	splitPath := filepath.Join(dir, "work.txt")
+
+	// The task was completed in the split file, then a sync added it to the todo file again
+	os.WriteFile(splitPath, []byte("x 2024-10-05 2024-10-01 Fix login +work url:https://example.org/1 id:tI4JeTHbMqhXUS9Ig0Pg9t\n"), 0640)
+	os.WriteFile(path, []byte("2024-10-01 Fix login page +work url:https://example.org/1\n"), 0640)
+
+	result, err := SplitTodoFile(path, SplitConfig{Filter: []string{"+work"}, To: "work.txt"})
+	if err != nil {
+		t.Fatalf("SplitTodoFile() failed: %v", err)
+	}
+	if result.Updated != 1 {
+		t.Errorf("SplitTodoFile() updated %d tasks, want 1", result.Updated)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Field != "text" {
+		t.Errorf("SplitTodoFile() conflicts = %+v, want the differing text", result.Conflicts)
+	}
+	want := "x 2024-10-05 2024-10-01 Fix login page +work id:tI4JeTHbMqhXUS9Ig0Pg9t url:https://example.org/1\n"
+	if split := readFile(t, splitPath); split != want {
+		t.Errorf("split file = %q, want %q", split, want)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(bs)
+}
@@ -70,6 +70,12 @@ func shouldUpdateTask(existing, source *todo.Task) bool {
         return true
     }
     
+    // Check if estimate has changed, an estimate set by the user is kept if the source has none
+    sourceEst, sourceHasEst := source.AdditionalTags["est"]
+    if sourceHasEst && existing.AdditionalTags["est"] != sourceEst {
+        return true
+    }
+    
     return false
 }
 
@@ -85,13 +91,20 @@ func updateTaskContent(existing, source *todo.Task) {
     existing.Projects = source.Projects
     existing.Contexts = source.Contexts
     
-    // Update additional tags
-    //uuid := existing.AdditionalTags["uuid"]
+    // Update additional tags, keeping the task id and an estimate the source does not provide
+    kept := make(map[string]string)
+    for _, key := range []string{"id", "uuid", "est"} {
+        if value, ok := existing.AdditionalTags[key]; ok {
+            kept[key] = value
+        }
+    }
     existing.AdditionalTags = make(map[string]string)
+    for k, v := range kept {
+        existing.AdditionalTags[k] = v
+    }
     for k, v := range source.AdditionalTags {
         existing.AdditionalTags[k] = v
     }
-    //existing.AdditionalTags["uuid"] = uuid
     
     // Restore completion status
     existing.Completed = wasCompleted
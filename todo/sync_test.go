@@ -0,0 +1,41 @@
+package todo
+
+import "testing"
+
+func TestSyncTaskListsKeepsEstimateAndID(t *testing.T) {
+	target := parseTaskList(t, "Fix login url:https://example.org/1 est:3h id:tI4JeTHbMqhXUS9Ig0Pg9t")
+	source := parseTaskList(t, "Fix login url:https://example.org/1")
+
+	// A user-set estimate is no reason to update when the source has none
+	target, result, err := SyncTaskLists(target, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Updated != 0 || result.Skipped != 1 {
+		t.Errorf("SyncTaskLists() = %+v, want the task skipped", result)
+	}
+
+	// Updates keep the id and the estimate
+	source = parseTaskList(t, "Fix login page url:https://example.org/1")
+	target, result, err = SyncTaskLists(target, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Updated != 1 {
+		t.Fatalf("SyncTaskLists() = %+v, want the task updated", result)
+	}
+	tags := target[0].AdditionalTags
+	if target[0].Todo != "Fix login page" || tags["est"] != "3h" || tags["id"] != "tI4JeTHbMqhXUS9Ig0Pg9t" {
+		t.Errorf("updated task = %q, want new text with est:3h and the id", target[0].String())
+	}
+
+	// An estimate from the source replaces the user's
+	source = parseTaskList(t, "Fix login page url:https://example.org/1 est:5h")
+	target, result, err = SyncTaskLists(target, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Updated != 1 || target[0].AdditionalTags["est"] != "5h" {
+		t.Errorf("SyncTaskLists() = %+v with task %q, want est:5h", result, target[0].String())
+	}
+}
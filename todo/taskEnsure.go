@@ -93,6 +93,18 @@ func ensureIdentifier(task *todo.Task, preferShortIDs bool) (uuidv7.UUID) {
 	return id
 }
 
+// TaskIdentifier returns the UUID stored in a task's id or uuid tag
+func TaskIdentifier(task *todo.Task) (uuidv7.UUID, bool) {
+	for _, key := range []string{"id", "uuid"} {
+		if idStr, exists := task.AdditionalTags[key]; exists {
+			if id, err := utils.DecodeUUID(idStr); err == nil {
+				return id, true
+			}
+		}
+	}
+	return uuidv7.Nil, false
+}
+
 // ensureDefaultTags ensures all default tags are present
 func ensureTags(task *todo.Task, tags map[string]string) {
 	if task.AdditionalTags == nil {
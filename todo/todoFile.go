@@ -1,8 +1,11 @@
 package todo
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"os"
+	"strings"
 	todo "github.com/1set/todotxt"
 )
 
@@ -17,6 +20,10 @@ func (e *FileError) Error() string {
 	return fmt.Sprintf("todo file %s error at %s: %v", e.Op, e.Path, e.Err)
 }
 
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
 // ReadTodoFile reads a todo.txt file and returns a TaskList
 func ReadTodoFile(path string) (todo.TaskList, error) {
 	file, err := os.Open(path)
@@ -47,3 +54,36 @@ func WriteTodoFile(taskList todo.TaskList, path string) error {
 
 	return nil
 }
+
+// ParseTaskList parses the content of a todo.txt file, skipping blank lines and comments like todotxt.LoadFromFile
+func ParseTaskList(content []byte) (todo.TaskList, error) {
+	taskList, _, err := parseTaskListWithComments(content)
+	return taskList, err
+}
+
+// parseTaskListWithComments parses the content of a todo.txt file like ParseTaskList and also
+// returns the comment lines preceding each task. The last entry holds the comments after the last task.
+func parseTaskListWithComments(content []byte) (todo.TaskList, [][]string, error) {
+	taskList := todo.NewTaskList()
+	var comments [][]string
+	var pending []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if todo.IgnoreComments && strings.HasPrefix(line, "#") {
+			pending = append(pending, line)
+			continue
+		}
+		task, err := todo.ParseTask(line)
+		if err != nil {
+			return nil, nil, err
+		}
+		taskList.AddTask(task)
+		comments = append(comments, pending)
+		pending = nil
+	}
+	return taskList, append(comments, pending), scanner.Err()
+}
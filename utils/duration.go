@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// FormatEstimate formats a duration for an est: tag using hours and minutes, e.g. 2h, 1h30m or 45m
+func FormatEstimate(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours := int(d / time.Hour)
+	minutes := int((d % time.Hour) / time.Minute)
+
+	switch {
+	case hours > 0 && minutes > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%dh", hours)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
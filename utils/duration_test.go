@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatEstimate(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{2 * time.Hour, "2h"},
+		{90 * time.Minute, "1h30m"},
+		{45 * time.Minute, "45m"},
+		{30 * time.Second, "1m"},
+		{0, "0m"},
+	}
+	for _, tt := range tests {
+		if got := FormatEstimate(tt.in); got != tt.want {
+			t.Errorf("FormatEstimate(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}